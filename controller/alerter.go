@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/madjlzz/madprobe/internal/alerter"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AlerterResponse represents the data structure
+// send to clients when they are trying to fetch receivers from the API.
+// It is encoded in JSON.
+type AlerterResponse struct {
+	Name        string
+	Sent        uint64
+	Failed      uint64
+	Pending     int
+	LastError   string
+	LastFailure *time.Time
+}
+
+// DeadLetterResponse represents the data structure
+// send to clients when they are trying to fetch undelivered notifications from the API.
+// It is encoded in JSON.
+type DeadLetterResponse struct {
+	ID        uint64
+	Receiver  string
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+	// Names of the probes of the notification.
+	Probes []string
+	Repeat bool
+}
+
+// AlerterController is the controller
+// exposing endpoints to inspect the receivers alerts are sent to.
+type AlerterController struct {
+	AlerterService alerter.AlerterService
+}
+
+// NewAlerterController initialize a new AlerterController
+// to expose endpoints for inspecting receivers.
+func NewAlerterController(as alerter.AlerterService) AlerterController {
+	return AlerterController{
+		AlerterService: as,
+	}
+}
+
+// ReadAll allows consumer to retrieve the delivery metrics of every receiver.
+// It will return a HTTP 200 status code with all receiver's metrics if it succeeds, a human readable error otherwise.
+//
+// GET /api/v1/alerters
+func (ac *AlerterController) ReadAll(w http.ResponseWriter, req *http.Request) {
+	ar := make([]AlerterResponse, 0)
+	for _, m := range ac.AlerterService.Metrics() {
+		r := AlerterResponse{
+			Name:      m.Name,
+			Sent:      m.Sent,
+			Failed:    m.Failed,
+			Pending:   m.Pending,
+			LastError: m.LastError,
+		}
+		if !m.LastFailure.IsZero() {
+			lastFailure := m.LastFailure
+			r.LastFailure = &lastFailure
+		}
+		ar = append(ar, r)
+	}
+
+	err := encodeJSONBody(w, &ar)
+	if err != nil {
+		var mr *malformedContent
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.Println(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// Preview allows consumer to render a sample notification with the template of a receiver.
+// It will return a HTTP 200 status code with the rendered message if it succeeds, a human readable error otherwise.
+//
+// GET /api/v1/alerters/{name}/preview
+func (ac *AlerterController) Preview(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	msg, err := ac.AlerterService.Preview(vars["name"])
+	if err != nil {
+		switch err {
+		case alerter.ErrReceiverNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprint(w, msg)
+}
+
+// ReadAllDeadLetters allows consumer to retrieve the notifications that could not be delivered.
+// It will return a HTTP 200 status code with all dead letters if it succeeds, a human readable error otherwise.
+//
+// GET /api/v1/alerters/dead-letters
+func (ac *AlerterController) ReadAllDeadLetters(w http.ResponseWriter, req *http.Request) {
+	deadLetters, err := ac.AlerterService.DeadLetters()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	dr := make([]DeadLetterResponse, 0)
+	for _, deadLetter := range deadLetters {
+		r := DeadLetterResponse{
+			ID:        deadLetter.ID,
+			Receiver:  deadLetter.Receiver,
+			Attempts:  deadLetter.Attempts,
+			LastError: deadLetter.LastError,
+			CreatedAt: deadLetter.CreatedAt,
+			Probes:    make([]string, 0),
+			Repeat:    deadLetter.Notification.Repeat,
+		}
+		for _, event := range deadLetter.Notification.Events {
+			r.Probes = append(r.Probes, event.Probe.Name)
+		}
+		dr = append(dr, r)
+	}
+
+	err = encodeJSONBody(w, &dr)
+	if err != nil {
+		var mr *malformedContent
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.Println(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// Replay allows consumer to queue a dead letter again for delivery.
+// It will return a HTTP 200 status code if it succeeds, a human readable error otherwise.
+//
+// POST /api/v1/alerters/dead-letters/{id}/replay
+func (ac *AlerterController) Replay(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	id, err := strconv.ParseUint(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "dead letter id must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	err = ac.AlerterService.Replay(id)
+	if err != nil {
+		switch err {
+		case alerter.ErrDeadLetterNotFound, alerter.ErrReceiverNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Dead letter [%d] has been successfuly queued again.", id)
+}
@@ -3,20 +3,29 @@
 package controller
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"github.com/madjlzz/madprobe/internal/alerter"
+	"github.com/madjlzz/madprobe/internal/labels"
 	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
 	"log"
 	"net/http"
+	"time"
 )
 
 // CreateProbeRequest represents the data structure
 // decoded from incoming HTTP request when trying to create a new probe.
+// Options holds the settings of the kind of probe selected by the scheme of the URL.
 type CreateProbeRequest struct {
-	Name  string
-	URL   string
-	Delay uint
+	Name     string
+	URL      string
+	Options  json.RawMessage
+	Delay    uint
+	Labels   map[string]string
+	Severity string
 }
 
 // UpdateProbeRequest represents the data structure
@@ -27,27 +36,61 @@ type UpdateProbeRequest struct {
 	Delay uint
 }
 
+// AckProbeRequest represents the data structure
+// decoded from incoming HTTP request when trying to acknowledge the outage of a probe.
+// The body is optional, Expiry is a Go duration (e.g. "2h"), the acknowledgement lasts until
+// the probe recovers when empty.
+type AckProbeRequest struct {
+	By      string
+	Comment string
+	Expiry  string
+}
+
 // ProbeResponse represents the data structure
 // send to clients when they are trying to fetch information from the API.
+// Silences lists the names of the ongoing silences muting the probe.
+// Acknowledgement is set while the outage of the probe is acknowledged.
+// Metrics holds the measures of the last check, Message its output when the target describes its health.
 // It is encoded in JSON.
 type ProbeResponse struct {
-	Name   string
-	URL    string
-	Status string
-	Delay  uint
+	Name            string
+	URL             string
+	Options         json.RawMessage `json:",omitempty"`
+	Status          string
+	Delay           uint
+	Labels          map[string]string
+	Severity        string
+	Silences        []string
+	Paused          bool
+	Metrics         map[string]float64
+	Message         string `json:",omitempty"`
+	Acknowledgement *AcknowledgementResponse
+}
+
+// AcknowledgementResponse represents the acknowledgement of a probe outage.
+// It is encoded in JSON.
+type AcknowledgementResponse struct {
+	By        string
+	Comment   string
+	At        time.Time
+	ExpiresAt *time.Time
 }
 
 // ProbeController is the controller
 // exposing endpoints to manage probes.
 type ProbeController struct {
-	ProbeService prober.ProbeService
+	ProbeService   prober.ProbeService
+	SilenceService silencer.SilenceService
+	AlerterService alerter.AlerterService
 }
 
 // NewProbeController initialize a new ProbeController
 // to expose endpoints for managing probes.
-func NewProbeController(ps prober.ProbeService) ProbeController {
+func NewProbeController(ps prober.ProbeService, ss silencer.SilenceService, as alerter.AlerterService) ProbeController {
 	return ProbeController{
-		ProbeService: ps,
+		ProbeService:   ps,
+		SilenceService: ss,
+		AlerterService: as,
 	}
 }
 
@@ -71,10 +114,13 @@ func (pc *ProbeController) Create(w http.ResponseWriter, req *http.Request) {
 	}
 
 	err = pc.ProbeService.Insert(prober.Probe{
-		Name:   cpr.Name,
-		URL:    cpr.URL,
-		Delay:  cpr.Delay,
-		Finish: make(chan bool, 1),
+		Name:     cpr.Name,
+		URL:      cpr.URL,
+		Options:  cpr.Options,
+		Delay:    cpr.Delay,
+		Labels:   cpr.Labels,
+		Severity: cpr.Severity,
+		Finish:   make(chan bool, 1),
 	})
 	if err != nil {
 		switch err {
@@ -108,12 +154,7 @@ func (pc *ProbeController) Read(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Encode the probe in json and send it over to the client
-	pr := ProbeResponse{
-		Name:   probe.Name,
-		URL:    probe.URL,
-		Status: probe.Status,
-		Delay:  probe.Delay,
-	}
+	pr := pc.newProbeResponse(probe)
 	err = encodeJSONBody(w, &pr)
 	if err != nil {
 		var mr *malformedContent
@@ -128,10 +169,17 @@ func (pc *ProbeController) Read(w http.ResponseWriter, req *http.Request) {
 }
 
 // GetAll allows consumer to retrieve all probe existing in the system.
+// Probes can be filtered with a label selector, e.g. ?selector=team=payments,env!=dev
 // It will return a HTTP 200 status code with all probe's details if it succeeds, a human readable error otherwise.
 //
 // GET /api/v1/probe
 func (pc *ProbeController) ReadAll(w http.ResponseWriter, req *http.Request) {
+	selector, err := labels.Parse(req.URL.Query().Get("selector"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	probes, err := pc.ProbeService.GetAll()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -140,12 +188,10 @@ func (pc *ProbeController) ReadAll(w http.ResponseWriter, req *http.Request) {
 
 	pr := make([]ProbeResponse, 0)
 	for _, value := range probes {
-		pr = append(pr, ProbeResponse{
-			Name:   value.Name,
-			URL:    value.URL,
-			Status: value.Status,
-			Delay:  value.Delay,
-		})
+		if !selector.Matches(value.Labels) {
+			continue
+		}
+		pr = append(pr, pc.newProbeResponse(value))
 	}
 
 	err = encodeJSONBody(w, &pr)
@@ -181,3 +227,91 @@ func (pc *ProbeController) Delete(w http.ResponseWriter, req *http.Request) {
 
 	_, _ = fmt.Fprintf(w, "Probe [%s] has been successfuly deleted.", vars["name"])
 }
+
+// Ack allows consumer to acknowledge the outage of a probe, which stops the reminders about it
+// until it recovers or the acknowledgement expires.
+// It will return a HTTP 200 status code if it succeeds, a human readable error otherwise.
+//
+// POST /api/v1/probe/{name}/ack
+func (pc *ProbeController) Ack(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	var apr AckProbeRequest
+	if req.ContentLength != 0 {
+		err := decodeJSONBody(w, req, &apr)
+		if err != nil {
+			var mr *malformedContent
+			if errors.As(err, &mr) {
+				http.Error(w, mr.msg, mr.status)
+			} else {
+				log.Println(err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	ack := alerter.Acknowledgement{
+		Probe:   vars["name"],
+		By:      apr.By,
+		Comment: apr.Comment,
+		At:      time.Now(),
+	}
+	if apr.Expiry != "" {
+		expiry, err := time.ParseDuration(apr.Expiry)
+		if err != nil || expiry <= 0 {
+			http.Error(w, fmt.Sprintf("Expiry [%s] is not a valid duration", apr.Expiry), http.StatusBadRequest)
+			return
+		}
+		ack.ExpiresAt = ack.At.Add(expiry)
+	}
+
+	_, err := pc.ProbeService.Get(vars["name"])
+	if err == nil {
+		err = pc.AlerterService.Acknowledge(ack)
+	}
+	if err != nil {
+		switch err {
+		case prober.ErrProbeNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case alerter.ErrProbeNotDown:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Probe [%s] has been successfuly acknowledged.", vars["name"])
+}
+
+func (pc *ProbeController) newProbeResponse(probe *prober.Probe) ProbeResponse {
+	silences := make([]string, 0)
+	for _, silence := range pc.SilenceService.Active(*probe) {
+		silences = append(silences, silence.Name)
+	}
+	pr := ProbeResponse{
+		Name:     probe.Name,
+		URL:      probe.URL,
+		Status:   probe.Status,
+		Delay:    probe.Delay,
+		Labels:   probe.Labels,
+		Severity: probe.EffectiveSeverity(),
+		Silences: silences,
+		Paused:   probe.Paused(),
+		Metrics:  probe.Metrics,
+		Message:  probe.Message,
+		Options:  probe.Options,
+	}
+	if ack := pc.AlerterService.Acknowledgement(probe.Name); ack != nil {
+		pr.Acknowledgement = &AcknowledgementResponse{
+			By:      ack.By,
+			Comment: ack.Comment,
+			At:      ack.At,
+		}
+		if !ack.ExpiresAt.IsZero() {
+			pr.Acknowledgement.ExpiresAt = &ack.ExpiresAt
+		}
+	}
+	return pr
+}
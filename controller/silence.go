@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/madjlzz/madprobe/internal/silencer"
+	"log"
+	"net/http"
+	"time"
+)
+
+// CreateSilenceRequest represents the data structure
+// decoded from incoming HTTP request when trying to create a new silence.
+// Probes are targeted by a name regular expression, a label selector or both.
+// Leave Schedule empty for a one-off silence, Duration is a Go duration (e.g. "2h").
+type CreateSilenceRequest struct {
+	Name     string
+	Probe    string
+	Selector string
+	Comment  string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Schedule string
+	Duration string
+}
+
+// SilenceResponse represents the data structure
+// send to clients when they are trying to fetch silences from the API.
+// It is encoded in JSON.
+type SilenceResponse struct {
+	Name     string
+	Probe    string
+	Selector string
+	Comment  string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Schedule string
+	Duration string
+	Active   bool
+}
+
+// SilenceController is the controller
+// exposing endpoints to manage silences and maintenance windows.
+type SilenceController struct {
+	SilenceService silencer.SilenceService
+}
+
+// NewSilenceController initialize a new SilenceController
+// to expose endpoints for managing silences.
+func NewSilenceController(ss silencer.SilenceService) SilenceController {
+	return SilenceController{
+		SilenceService: ss,
+	}
+}
+
+// Create allows consumer to create a new silence in the system.
+// It will return a HTTP 200 status code if it succeeds, a human readable error otherwise.
+//
+// POST /api/v1/silence/create
+func (sc *SilenceController) Create(w http.ResponseWriter, req *http.Request) {
+	var csr CreateSilenceRequest
+
+	err := decodeJSONBody(w, req, &csr)
+	if err != nil {
+		var mr *malformedContent
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.Println(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var duration time.Duration
+	if csr.Duration != "" {
+		duration, err = time.ParseDuration(csr.Duration)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Duration [%s] is not a valid duration", csr.Duration), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = sc.SilenceService.Insert(silencer.Silence{
+		Name:     csr.Name,
+		Probe:    csr.Probe,
+		Selector: csr.Selector,
+		Comment:  csr.Comment,
+		StartsAt: csr.StartsAt,
+		EndsAt:   csr.EndsAt,
+		Schedule: csr.Schedule,
+		Duration: duration,
+	})
+	if err != nil {
+		switch err {
+		case silencer.ErrSilenceAlreadyExist:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Silence [%s] has been successfuly created.", csr.Name)
+}
+
+// Read allows consumer to retrieve a silence in the system given it's name.
+// It will return a HTTP 200 status code with the silence's details if it succeeds, a human readable error otherwise.
+//
+// GET /api/v1/silence/{name}
+func (sc *SilenceController) Read(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	silence, err := sc.SilenceService.Get(vars["name"])
+	if err != nil {
+		switch err {
+		case silencer.ErrSilenceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	sr := newSilenceResponse(silence)
+	err = encodeJSONBody(w, &sr)
+	if err != nil {
+		var mr *malformedContent
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.Println(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// ReadAll allows consumer to retrieve all silences existing in the system.
+// It will return a HTTP 200 status code with all silence's details if it succeeds, a human readable error otherwise.
+//
+// GET /api/v1/silence
+func (sc *SilenceController) ReadAll(w http.ResponseWriter, req *http.Request) {
+	silences, err := sc.SilenceService.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sr := make([]SilenceResponse, 0)
+	for _, value := range silences {
+		sr = append(sr, newSilenceResponse(value))
+	}
+
+	err = encodeJSONBody(w, &sr)
+	if err != nil {
+		var mr *malformedContent
+		if errors.As(err, &mr) {
+			http.Error(w, mr.msg, mr.status)
+		} else {
+			log.Println(err.Error())
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// Delete allows consumer to delete an existing silence in the system.
+// It will return a HTTP 200 status code if it succeeds, a human readable error otherwise.
+//
+// DELETE /api/v1/silence/{name}
+func (sc *SilenceController) Delete(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+
+	err := sc.SilenceService.Delete(vars["name"])
+	if err != nil {
+		switch err {
+		case silencer.ErrSilenceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Silence [%s] has been successfuly deleted.", vars["name"])
+}
+
+func newSilenceResponse(silence *silencer.Silence) SilenceResponse {
+	sr := SilenceResponse{
+		Name:     silence.Name,
+		Probe:    silence.Probe,
+		Selector: silence.Selector,
+		Comment:  silence.Comment,
+		StartsAt: silence.StartsAt,
+		EndsAt:   silence.EndsAt,
+		Schedule: silence.Schedule,
+		Active:   silence.ActiveAt(time.Now()),
+	}
+	if silence.Duration > 0 {
+		sr.Duration = silence.Duration.String()
+	}
+	return sr
+}
@@ -4,10 +4,16 @@ go 1.13
 
 require (
 	github.com/boltdb/bolt v1.3.1
-	github.com/bwmarrin/discordgo v0.20.3
+	github.com/bwmarrin/discordgo v0.27.1
 	github.com/golang/mock v1.4.3
 	github.com/gorilla/mux v1.7.4
+	github.com/gorilla/websocket v1.4.2
+	github.com/lib/pq v1.10.2
+	github.com/miekg/dns v1.1.43
 	github.com/pkg/errors v0.8.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.7.0
+	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
+	google.golang.org/grpc v1.40.0
 )
@@ -0,0 +1,116 @@
+package alerter
+
+import (
+	"errors"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"log"
+	"time"
+)
+
+// Error thrown whenever a probe that is not DOWN is acknowledged.
+var ErrProbeNotDown = errors.New("probe is not down")
+
+// Acknowledgement records that someone is working on the outage of a probe.
+// Reminders about the probe stop until it recovers or the acknowledgement expires.
+type Acknowledgement struct {
+	Probe   string
+	By      string
+	Comment string
+	At      time.Time
+	// Zero when the acknowledgement lasts until the probe recovers.
+	ExpiresAt time.Time
+}
+
+// ActiveAt returns true if the acknowledgement has not expired at the given time.
+func (a Acknowledgement) ActiveAt(t time.Time) bool {
+	return a.ExpiresAt.IsZero() || t.Before(a.ExpiresAt)
+}
+
+// Acknowledger is implemented by the alerters letting users acknowledge alerts from the receiver,
+// acknowledge is called with every acknowledgement received.
+type Acknowledger interface {
+	OnAcknowledge(acknowledge func(Acknowledgement) error)
+}
+
+// Acknowledge stops the reminders about a DOWN probe until it recovers or the acknowledgement expires.
+// It returns ErrProbeNotDown if the probe is not DOWN.
+// The escalation of the probe stops too. The acknowledgement is persisted so that it survives restarts.
+func (s *service) Acknowledge(ack Acknowledgement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.down[ack.Probe] {
+		return ErrProbeNotDown
+	}
+	if ack.At.IsZero() {
+		ack.At = time.Now()
+	}
+	if s.acknowledgements != nil {
+		entity := persistence.AcknowledgementEntity(ack)
+		if err := s.acknowledgements.InsertAcknowledgement(&entity); err != nil {
+			return err
+		}
+	}
+	s.acks[ack.Probe] = &ack
+	return nil
+}
+
+// Acknowledgement returns the active acknowledgement of the probe, nil if there is none.
+func (s *service) Acknowledgement(probe string) *Acknowledgement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ack, ok := s.acks[probe]
+	if !ok || !ack.ActiveAt(time.Now()) {
+		return nil
+	}
+	result := *ack
+	return &result
+}
+
+func (s *service) acknowledged(probe string) bool {
+	return s.Acknowledgement(probe) != nil
+}
+
+// track records whether the probe of the event is DOWN, and clears its acknowledgement when it is not.
+func (s *service) track(event prober.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.Probe.Status == prober.DownStatus {
+		s.down[event.Probe.Name] = true
+		return
+	}
+	delete(s.down, event.Probe.Name)
+	if _, ok := s.acks[event.Probe.Name]; !ok {
+		return
+	}
+	delete(s.acks, event.Probe.Name)
+	if s.acknowledgements != nil {
+		if err := s.acknowledgements.DeleteAcknowledgement(event.Probe.Name); err != nil {
+			log.Printf("[WARNING] acknowledgement of probe [%s] could not be deleted. got: [%v]\n", event.Probe.Name, err)
+		}
+	}
+}
+
+// restoreAcknowledgements restores the acknowledgements of a previous run, the acknowledged probes
+// being DOWN until they are checked again. Expired acknowledgements are dropped.
+func (s *service) restoreAcknowledgements() {
+	entities, err := s.acknowledgements.GetAllAcknowledgements()
+	if err != nil {
+		log.Printf("[WARNING] acknowledgements could not be restored. got: [%v]\n", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, entity := range entities {
+		ack := Acknowledgement(*entity)
+		if !ack.ActiveAt(now) {
+			if err := s.acknowledgements.DeleteAcknowledgement(ack.Probe); err != nil {
+				log.Printf("[WARNING] acknowledgement of probe [%s] could not be deleted. got: [%v]\n", ack.Probe, err)
+			}
+			continue
+		}
+		s.down[ack.Probe] = true
+		s.acks[ack.Probe] = &ack
+	}
+}
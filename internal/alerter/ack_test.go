@@ -0,0 +1,104 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake of the interface AcknowledgementPersister keeping the acknowledgements in memory.
+type fakeAcknowledgements struct {
+	mu               sync.Mutex
+	acknowledgements map[string]persistence.AcknowledgementEntity
+}
+
+func newFakeAcknowledgements() *fakeAcknowledgements {
+	return &fakeAcknowledgements{acknowledgements: make(map[string]persistence.AcknowledgementEntity)}
+}
+
+func (f *fakeAcknowledgements) InsertAcknowledgement(entity *persistence.AcknowledgementEntity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acknowledgements[entity.Probe] = *entity
+	return nil
+}
+
+func (f *fakeAcknowledgements) GetAllAcknowledgements() ([]*persistence.AcknowledgementEntity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var all []*persistence.AcknowledgementEntity
+	for _, entity := range f.acknowledgements {
+		entity := entity
+		all = append(all, &entity)
+	}
+	return all, nil
+}
+
+func (f *fakeAcknowledgements) DeleteAcknowledgement(probe string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.acknowledgements, probe)
+	return nil
+}
+
+func TestAcknowledgeUntilRecovery(t *testing.T) {
+	s := newService(make(chan prober.Event), &fakeSilences{}, newFakeDeliveries(), &Configuration{})
+	if err := s.Acknowledge(Acknowledgement{Probe: "api"}); err != ErrProbeNotDown {
+		t.Errorf("probe that is not down can't be acknowledged. got: %v\n", err)
+	}
+
+	s.track(newTestEvent("api", prober.DownStatus))
+	if err := s.Acknowledge(Acknowledgement{Probe: "api", By: "jane", Comment: "on it"}); err != nil {
+		t.Fatalf("down probe should be acknowledged. got: %v\n", err)
+	}
+	ack := s.Acknowledgement("api")
+	if ack == nil || ack.By != "jane" || ack.At.IsZero() {
+		t.Errorf("acknowledgement should be active. got: %+v\n", ack)
+	}
+
+	s.track(newTestEvent("api", prober.UpStatus))
+	if ack := s.Acknowledgement("api"); ack != nil {
+		t.Errorf("acknowledgement should be cleared on recovery. got: %+v\n", ack)
+	}
+}
+
+func TestAcknowledgementExpires(t *testing.T) {
+	s := newService(make(chan prober.Event), &fakeSilences{}, newFakeDeliveries(), &Configuration{})
+	s.track(newTestEvent("api", prober.DownStatus))
+	_ = s.Acknowledge(Acknowledgement{Probe: "api", ExpiresAt: time.Now().Add(-time.Second)})
+	if ack := s.Acknowledgement("api"); ack != nil {
+		t.Errorf("expired acknowledgement should not be active. got: %+v\n", ack)
+	}
+}
+
+func TestAcknowledgementIsRestored(t *testing.T) {
+	acknowledgements := newFakeAcknowledgements()
+	s := newService(make(chan prober.Event), &fakeSilences{}, newFakeDeliveries(), &Configuration{})
+	s.acknowledgements = acknowledgements
+	s.track(newTestEvent("api", prober.DownStatus))
+	s.track(newTestEvent("db", prober.DownStatus))
+	_ = s.Acknowledge(Acknowledgement{Probe: "api", By: "jane", ExpiresAt: time.Now().Add(time.Hour)})
+	_ = s.Acknowledge(Acknowledgement{Probe: "db", By: "john"})
+	acknowledgements.acknowledgements["cache"] = persistence.AcknowledgementEntity{Probe: "cache", ExpiresAt: time.Now().Add(-time.Second)}
+
+	restarted := newService(make(chan prober.Event), &fakeSilences{}, newFakeDeliveries(), &Configuration{})
+	restarted.acknowledgements = acknowledgements
+	restarted.restoreAcknowledgements()
+	if ack := restarted.Acknowledgement("api"); ack == nil || ack.By != "jane" {
+		t.Errorf("acknowledgement of a probe without escalation should be restored. got: %+v\n", ack)
+	}
+	if _, ok := acknowledgements.acknowledgements["cache"]; ok {
+		t.Errorf("expired acknowledgement should be dropped\n")
+	}
+
+	restarted.track(newTestEvent("api", prober.DownStatus))
+	restarted.track(newTestEvent("db", prober.UpStatus))
+	if ack := restarted.Acknowledgement("api"); ack == nil {
+		t.Errorf("acknowledgement should survive the first check of a probe still DOWN\n")
+	}
+	if _, ok := acknowledgements.acknowledgements["db"]; ok || restarted.Acknowledgement("db") != nil {
+		t.Errorf("acknowledgement should be deleted on recovery\n")
+	}
+}
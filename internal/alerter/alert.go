@@ -1,14 +1,18 @@
 package alerter
 
 import (
+	"crypto/tls"
 	"github.com/bwmarrin/discordgo"
 	"github.com/madjlzz/madprobe/internal/prober"
 	"io"
+	"net/http"
+	"sync"
 )
 
 // Base type that defines an Alerter.
+// Alert delivers a notification and returns an error if it could not be sent.
 type Alerter interface {
-	Alert(eventBus <-chan prober.Probe)
+	Alert(notification Notification) error
 }
 
 // More specific type of an Alerter that has to close one of it's resource.
@@ -17,8 +21,83 @@ type AlertCloser interface {
 	io.Closer
 }
 
+// Notification is the message sent to a receiver about one or more probes.
+type Notification struct {
+	// The receiver the notification is routed to.
+	Receiver string
+	// Destinations of the route overriding the receiver defaults, if any.
+	Recipients []string
+	// Values of the group-by labels shared by the probes.
+	GroupLabels map[string]string
+	// The status changes of the probes, sorted by probe name.
+	Events []prober.Event
+	// Repeat is set when the notification reminds about probes that are still DOWN.
+	Repeat bool
+}
+
 // Implementation of an alerter that pushes notification to a Discord channel.
 type DiscordAlerter struct {
 	channelID string
 	session   *discordgo.Session
+	template  *messageTemplate
+	// Answers the slash commands, nil when they are disabled.
+	commands *discordCommands
+
+	mu          sync.Mutex
+	acknowledge func(Acknowledgement) error
+	// DOWN probes of the last alert messages by message ID, oldest messages first in sent.
+	messages map[string][]string
+	sent     []string
+}
+
+// Implementation of an alerter that posts notifications to a Slack incoming webhook.
+type SlackAlerter struct {
+	webhookURL string
+	client     *http.Client
+	template   *messageTemplate
+}
+
+// Implementation of an alerter that posts notifications to a Microsoft Teams incoming webhook.
+type TeamsAlerter struct {
+	webhookURL string
+	client     *http.Client
+	template   *messageTemplate
+}
+
+// Implementation of an alerter that opens and resolves incidents through the PagerDuty Events API v2.
+type PagerDutyAlerter struct {
+	config   *PagerDutyConfiguration
+	client   *http.Client
+	template *messageTemplate
+}
+
+// Implementation of an alerter that creates and closes Opsgenie alerts.
+type OpsgenieAlerter struct {
+	config   *OpsgenieConfiguration
+	client   *http.Client
+	template *messageTemplate
+}
+
+// Implementation of an alerter that sends notifications to Telegram chats through a bot.
+type TelegramAlerter struct {
+	config   *TelegramConfiguration
+	client   *http.Client
+	template *messageTemplate
+}
+
+// Implementation of an alerter that runs a local command on status changes.
+type ExecAlerter struct {
+	config   *ExecConfiguration
+	template *messageTemplate
+	// Buffered to the concurrency limit, a command runs while holding a slot.
+	slots chan struct{}
+}
+
+// Implementation of an alerter that sends notifications by email through an SMTP server.
+type EmailAlerter struct {
+	config    *EmailConfiguration
+	text      *messageTemplate
+	subject   *messageTemplate
+	html      *messageTemplate
+	tlsConfig *tls.Config
 }
@@ -2,18 +2,175 @@ package alerter
 
 import (
 	"errors"
+	"fmt"
 	"github.com/spf13/viper"
+	"net/mail"
+	"net/url"
+	"os/exec"
+	"time"
 )
 
 var ErrDiscordChannelNotValid = errors.New("channel id must be set")
 var ErrDiscordTokenNotValid = errors.New("token must be set")
 
+// Name of the receiver created from the discord flags when no receiver is configured.
+const defaultDiscordReceiver = "discord"
+
+// Alerting struct holding the receivers and the routing tree read from the configuration file.
+type Configuration struct {
+	// Root URL of the madprobe API used to build links in the messages, e.g. https://madprobe.example.com
+	ExternalURL string `mapstructure:"external-url"`
+	// The alerter instances alerts can be routed to.
+	Receivers []ReceiverConfiguration `mapstructure:"receivers"`
+	// The root of the routing tree, it is the default route.
+	Route *Route `mapstructure:"route"`
+	// Retry policy of the notifications that could not be delivered.
+	Queue QueueConfiguration `mapstructure:"queue"`
+	// Tiers of receivers notified while DOWN probes are not acknowledged,
+	// probes reference their policy with the escalation label.
+	EscalationPolicies []EscalationPolicy `mapstructure:"escalation-policies"`
+}
+
+// EscalationPolicy struct holding the tiers of receivers notified while an outage is not acknowledged.
+type EscalationPolicy struct {
+	Name  string           `mapstructure:"name"`
+	Tiers []EscalationTier `mapstructure:"tiers"`
+}
+
+// EscalationTier struct holding the receivers notified once an outage lasts After without being acknowledged.
+type EscalationTier struct {
+	After     time.Duration `mapstructure:"after"`
+	Receivers []string      `mapstructure:"receivers"`
+}
+
+// Queue struct holding the retry policy of the receivers.
+type QueueConfiguration struct {
+	// Attempts after which a notification is moved to the dead letters, 10 when zero.
+	MaxAttempts int `mapstructure:"max-attempts"`
+	// Delay before the first retry, doubled after every failed attempt, 10s when zero.
+	InitialBackoff time.Duration `mapstructure:"initial-backoff"`
+	// Upper bound of the delay between two attempts, 10m when zero.
+	MaxBackoff time.Duration `mapstructure:"max-backoff"`
+}
+
+// Receiver struct holding the configuration of a named alerter instance.
+// Exactly one alerter configuration must be set.
+type ReceiverConfiguration struct {
+	Name string `mapstructure:"name"`
+	// Go text/template of the messages rendered against TemplateData, the alerter default when empty.
+	Template  string                  `mapstructure:"template"`
+	Discord   *DiscordConfiguration   `mapstructure:"discord"`
+	Email     *EmailConfiguration     `mapstructure:"email"`
+	Slack     *SlackConfiguration     `mapstructure:"slack"`
+	Teams     *TeamsConfiguration     `mapstructure:"teams"`
+	PagerDuty *PagerDutyConfiguration `mapstructure:"pagerduty"`
+	Opsgenie  *OpsgenieConfiguration  `mapstructure:"opsgenie"`
+	Telegram  *TelegramConfiguration  `mapstructure:"telegram"`
+	Exec      *ExecConfiguration      `mapstructure:"exec"`
+}
+
 // Discord struct holding default configuration option.
 type DiscordConfiguration struct {
 	// The ID of the Discord channel used for posting new alerts.
-	ChannelID string
+	ChannelID string `mapstructure:"channel-id"`
 	// The authentication Token to talk with the Discord API.
-	Token string
+	Token string `mapstructure:"token"`
+	// Registers the /probe slash commands of the bot.
+	Commands bool `mapstructure:"commands"`
+	// Registers the commands in this server only, where they are available right away, instead of globally.
+	GuildID string `mapstructure:"guild-id"`
+	// IDs of the roles allowed to run the commands changing probes, nobody when empty.
+	CommandRoles []string `mapstructure:"command-roles"`
+}
+
+// Slack struct holding the configuration of an incoming webhook alerter.
+type SlackConfiguration struct {
+	// The URL of the incoming webhook, e.g. https://hooks.slack.com/services/T000/B000/XXXX
+	WebhookURL string `mapstructure:"webhook-url"`
+}
+
+// Teams struct holding the configuration of an incoming webhook alerter.
+type TeamsConfiguration struct {
+	// The URL of the incoming webhook or of the Workflows trigger posting in the channel.
+	WebhookURL string `mapstructure:"webhook-url"`
+}
+
+// PagerDuty struct holding the configuration of an Events API v2 alerter.
+type PagerDutyConfiguration struct {
+	// The integration key of the PagerDuty service.
+	RoutingKey string `mapstructure:"routing-key"`
+	// The endpoint of the Events API, defaults to https://events.pagerduty.com/v2/enqueue
+	URL string `mapstructure:"url"`
+}
+
+// Opsgenie struct holding the configuration of an alert API alerter.
+type OpsgenieConfiguration struct {
+	// The key of the API integration of the Opsgenie team.
+	APIKey string `mapstructure:"api-key"`
+	// The root URL of the Opsgenie API, defaults to https://api.opsgenie.com
+	URL string `mapstructure:"url"`
+}
+
+// Telegram struct holding the configuration of a bot alerter.
+type TelegramConfiguration struct {
+	// The authentication token of the bot, given by the BotFather.
+	Token string `mapstructure:"token"`
+	// Default chats the bot posts in, overridden by the recipients of the routes.
+	ChatIDs []string `mapstructure:"chat-ids"`
+	// The root URL of the Bot API, defaults to https://api.telegram.org
+	URL string `mapstructure:"api-url"`
+}
+
+// Exec struct holding the configuration of a local command alerter.
+type ExecConfiguration struct {
+	// Path of the command, looked up in the PATH when it has no slash.
+	Command string   `mapstructure:"command"`
+	Args    []string `mapstructure:"args"`
+	// Duration after which the command is killed, 30s when zero.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Maximum number of commands running at the same time, 1 when zero. The queue delivers one notification
+	// at a time, so the limit applies to the status changes of a single notification.
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+// Security modes of the connection to the SMTP server.
+const (
+	// Upgrade the plain connection with the STARTTLS command, the default.
+	StartTLSSecurity = "starttls"
+	// Connect using TLS from the start, also known as SMTPS.
+	TLSSecurity = "tls"
+	// Never encrypt the connection.
+	NoSecurity = "none"
+)
+
+// SMTP authentication mechanisms.
+const (
+	PlainAuth = "plain"
+	LoginAuth = "login"
+)
+
+// Email struct holding the configuration of an SMTP alerter.
+type EmailConfiguration struct {
+	// Address of the SMTP server.
+	Host string `mapstructure:"host"`
+	// Port of the SMTP server, defaults to 587 with starttls, 465 with tls and 25 otherwise.
+	Port int `mapstructure:"port"`
+	// One of starttls, tls or none.
+	Security string `mapstructure:"security"`
+	// Skip the verification of the server certificate.
+	InsecureSkipVerify bool `mapstructure:"insecure-skip-verify"`
+	// One of plain or login, no authentication when empty.
+	Auth     string `mapstructure:"auth"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Sender address of the emails.
+	From string `mapstructure:"from"`
+	// Default recipients, overridden by the recipients of the routes.
+	To []string `mapstructure:"to"`
+	// Go text/template of the subject, a summary of the status changes when empty.
+	Subject string `mapstructure:"subject"`
+	// Go html/template of the HTML body, a table of the alerts when empty.
+	HTMLTemplate string `mapstructure:"html-template"`
 }
 
 // Default value of the ServerConfiguration struct.
@@ -22,6 +179,191 @@ var DefaultDiscordConfiguration = &DiscordConfiguration{
 	Token:     "",
 }
 
+// NewConfiguration reads the alerting section of the configuration file.
+// When no receiver is configured, a single "discord" receiver is built from the discord flags
+// if they are set, and every alert is routed to it.
+func NewConfiguration() (*Configuration, error) {
+	var c Configuration
+	if err := viper.UnmarshalKey("alerting", &c); err != nil {
+		return nil, err
+	}
+	if len(c.Receivers) == 0 && c.Route == nil {
+		dc, err := NewDiscordConfiguration()
+		if err != nil {
+			return &c, nil
+		}
+		c.Receivers = []ReceiverConfiguration{{Name: defaultDiscordReceiver, Discord: dc}}
+		c.Route = &Route{Receiver: defaultDiscordReceiver}
+	}
+	return &c, c.validate()
+}
+
+func (c *Configuration) validate() error {
+	if err := c.Queue.validate(); err != nil {
+		return err
+	}
+	receivers := make(map[string]bool)
+	for _, rc := range c.Receivers {
+		if err := rc.validate(); err != nil {
+			return err
+		}
+		if receivers[rc.Name] {
+			return fmt.Errorf("receiver [%s] is declared more than once", rc.Name)
+		}
+		receivers[rc.Name] = true
+	}
+	policies := make(map[string]bool)
+	for _, ep := range c.EscalationPolicies {
+		if err := ep.validate(receivers); err != nil {
+			return err
+		}
+		if policies[ep.Name] {
+			return fmt.Errorf("escalation policy [%s] is declared more than once", ep.Name)
+		}
+		policies[ep.Name] = true
+	}
+	if c.Route == nil {
+		if len(c.Receivers) > 0 {
+			return errors.New("a route must be set to use the receivers")
+		}
+		return nil
+	}
+	if c.Route.Selector != "" || len(c.Route.Severity) > 0 || c.Route.Continue {
+		return errors.New("the root route is the default route and can't have selector, severity or continue")
+	}
+	return c.Route.compile(nil, receivers)
+}
+
+// validate checks that the policy has tiers of existing receivers, notified one after the other.
+func (ep EscalationPolicy) validate(receivers map[string]bool) error {
+	if ep.Name == "" {
+		return errors.New("escalation policy name must be set")
+	}
+	if len(ep.Tiers) == 0 {
+		return fmt.Errorf("escalation policy [%s] must have at least one tier", ep.Name)
+	}
+	var after time.Duration
+	for i, tier := range ep.Tiers {
+		if tier.After < 0 {
+			return fmt.Errorf("tier %d of escalation policy [%s] must have a positive delay", i+1, ep.Name)
+		}
+		if tier.After < after {
+			return fmt.Errorf("tier %d of escalation policy [%s] must not come before the previous one", i+1, ep.Name)
+		}
+		after = tier.After
+		if len(tier.Receivers) == 0 {
+			return fmt.Errorf("tier %d of escalation policy [%s] must have at least one receiver", i+1, ep.Name)
+		}
+		for _, receiver := range tier.Receivers {
+			if !receivers[receiver] {
+				return fmt.Errorf("escalation policy [%s] references unknown receiver [%s]", ep.Name, receiver)
+			}
+		}
+	}
+	return nil
+}
+
+func (qc *QueueConfiguration) validate() error {
+	if qc.MaxAttempts < 0 || qc.InitialBackoff < 0 || qc.MaxBackoff < 0 {
+		return errors.New("queue max attempts and backoffs must be positive")
+	}
+	if qc.MaxAttempts == 0 {
+		qc.MaxAttempts = defaultMaxAttempts
+	}
+	if qc.InitialBackoff == 0 {
+		qc.InitialBackoff = defaultInitialBackoff
+	}
+	if qc.MaxBackoff == 0 {
+		qc.MaxBackoff = defaultMaxBackoff
+	}
+	if qc.MaxBackoff < qc.InitialBackoff {
+		return errors.New("queue max backoff must be greater than the initial backoff")
+	}
+	return nil
+}
+
+func (rc ReceiverConfiguration) validate() error {
+	if rc.Name == "" {
+		return errors.New("receiver name must be set")
+	}
+	var err error
+	switch {
+	case rc.alerters() != 1:
+		return fmt.Errorf("receiver [%s] must configure exactly one alerter", rc.Name)
+	case rc.Discord != nil:
+		err = rc.Discord.validate()
+	case rc.Email != nil:
+		err = rc.Email.validate(rc.Name)
+	case rc.Slack != nil:
+		err = validateWebhookURL(rc.Slack.WebhookURL)
+	case rc.Teams != nil:
+		err = validateWebhookURL(rc.Teams.WebhookURL)
+	case rc.PagerDuty != nil:
+		err = rc.PagerDuty.validate()
+	case rc.Opsgenie != nil:
+		err = rc.Opsgenie.validate()
+	case rc.Telegram != nil:
+		err = rc.Telegram.validate()
+	case rc.Exec != nil:
+		err = rc.Exec.validate()
+	}
+	if err != nil {
+		return fmt.Errorf("receiver [%s]: %w", rc.Name, err)
+	}
+	_, err = newMessageTemplate(rc.Name, rc.template(), "")
+	return err
+}
+
+// alerters counts the alerter configurations set on the receiver.
+func (rc ReceiverConfiguration) alerters() int {
+	count := 0
+	if rc.Discord != nil {
+		count++
+	}
+	if rc.Email != nil {
+		count++
+	}
+	if rc.Slack != nil {
+		count++
+	}
+	if rc.Teams != nil {
+		count++
+	}
+	if rc.PagerDuty != nil {
+		count++
+	}
+	if rc.Opsgenie != nil {
+		count++
+	}
+	if rc.Telegram != nil {
+		count++
+	}
+	if rc.Exec != nil {
+		count++
+	}
+	return count
+}
+
+// template returns the configured template of the receiver or the default one of its alerter.
+func (rc ReceiverConfiguration) template() string {
+	if rc.Template != "" {
+		return rc.Template
+	}
+	switch {
+	case rc.Email != nil:
+		return emailTemplate
+	case rc.Slack != nil:
+		return slackTemplate
+	case rc.Teams != nil:
+		return teamsTemplate
+	case rc.PagerDuty != nil, rc.Opsgenie != nil, rc.Exec != nil:
+		return incidentTemplate
+	case rc.Telegram != nil:
+		return telegramTemplate
+	}
+	return discordTemplate
+}
+
 func NewDiscordConfiguration() (*DiscordConfiguration, error) {
 	dc := &DiscordConfiguration{
 		ChannelID: viper.GetString("discord-channel-id"),
@@ -39,3 +381,132 @@ func (dc *DiscordConfiguration) validate() error {
 	}
 	return nil
 }
+
+func (ec *EmailConfiguration) validate(receiver string) error {
+	if ec.Host == "" {
+		return errors.New("email host must be set")
+	}
+	switch ec.Security {
+	case "":
+		ec.Security = StartTLSSecurity
+	case StartTLSSecurity, TLSSecurity, NoSecurity:
+	default:
+		return fmt.Errorf("email security [%s] must be one of starttls, tls or none", ec.Security)
+	}
+	if ec.Port == 0 {
+		ec.Port = defaultSMTPPorts[ec.Security]
+	}
+	if ec.Port < 0 || ec.Port > 65535 {
+		return fmt.Errorf("email port [%d] is invalid", ec.Port)
+	}
+	switch ec.Auth {
+	case "":
+	case PlainAuth, LoginAuth:
+		if ec.Username == "" {
+			return errors.New("email username must be set to authenticate")
+		}
+	default:
+		return fmt.Errorf("email auth [%s] must be one of plain or login", ec.Auth)
+	}
+	if ec.From == "" {
+		return errors.New("email sender must be set")
+	}
+	if _, err := mail.ParseAddress(ec.From); err != nil {
+		return fmt.Errorf("email sender [%s] is invalid: %w", ec.From, err)
+	}
+	for _, to := range ec.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("email recipient [%s] is invalid: %w", to, err)
+		}
+	}
+	if _, err := newMessageTemplate(receiver, ec.subject(), ""); err != nil {
+		return err
+	}
+	_, err := newHTMLMessageTemplate(receiver, ec.htmlTemplate(), "")
+	return err
+}
+
+// Ports of the SMTP server used when none is configured, by security mode.
+var defaultSMTPPorts = map[string]int{
+	StartTLSSecurity: 587,
+	TLSSecurity:      465,
+	NoSecurity:       25,
+}
+
+func (ec *EmailConfiguration) subject() string {
+	if ec.Subject != "" {
+		return ec.Subject
+	}
+	return emailSubjectTemplate
+}
+
+func (ec *EmailConfiguration) htmlTemplate() string {
+	if ec.HTMLTemplate != "" {
+		return ec.HTMLTemplate
+	}
+	return emailHTMLTemplate
+}
+
+func (pc *PagerDutyConfiguration) validate() error {
+	if pc.RoutingKey == "" {
+		return errors.New("pagerduty routing key must be set")
+	}
+	if pc.URL == "" {
+		pc.URL = defaultPagerDutyURL
+	}
+	return validateWebhookURL(pc.URL)
+}
+
+func (oc *OpsgenieConfiguration) validate() error {
+	if oc.APIKey == "" {
+		return errors.New("opsgenie api key must be set")
+	}
+	if oc.URL == "" {
+		oc.URL = defaultOpsgenieURL
+	}
+	return validateWebhookURL(oc.URL)
+}
+
+func (tc *TelegramConfiguration) validate() error {
+	if tc.Token == "" {
+		return errors.New("telegram token must be set")
+	}
+	if tc.URL == "" {
+		tc.URL = defaultTelegramURL
+	}
+	return validateWebhookURL(tc.URL)
+}
+
+func (ec *ExecConfiguration) validate() error {
+	if ec.Command == "" {
+		return errors.New("exec command must be set")
+	}
+	if _, err := exec.LookPath(ec.Command); err != nil {
+		return fmt.Errorf("exec command [%s] can't be run: %w", ec.Command, err)
+	}
+	if ec.Timeout < 0 || ec.Concurrency < 0 {
+		return errors.New("exec timeout and concurrency must be positive")
+	}
+	if ec.Timeout == 0 {
+		ec.Timeout = defaultExecTimeout
+	}
+	if ec.Concurrency == 0 {
+		ec.Concurrency = defaultExecConcurrency
+	}
+	return nil
+}
+
+// validateWebhookURL checks the URL is an absolute HTTP(S) URL.
+func validateWebhookURL(raw string) error {
+	if raw == "" {
+		return errors.New("webhook url must be set")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("webhook url is invalid: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook url [%s] must be an absolute http or https URL", raw)
+	}
+	return nil
+}
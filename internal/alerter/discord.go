@@ -5,43 +5,128 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/madjlzz/madprobe/internal/prober"
 	"log"
+	"strings"
 	"time"
 )
 
-func NewDiscordAlerter() *DiscordAlerter {
-	dc, err := NewDiscordConfiguration()
-	if err != nil {
-		log.Printf("[WARNING] discord configuration contains an error/errors. got: [%v]\n", err)
-		return nil
-	}
+// Default template of the Discord embeds description.
+const discordTemplate = `{{ range .Alerts -}}
+**{{ .Name }}** {{ if .Recovered }}recovered after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }}is still {{ .Status }} for {{ duration .OutageDuration }}{{ else }}is {{ .Status }}{{ end }}{{ if .URL }} - {{ .URL }}{{ end }}
+{{ if .LastError }}{{ if ne .Status "UP" }}> {{ .LastError }}{{ else }}> Last error: {{ .LastError }}{{ end }}
+{{ end }}{{ if .ProbeURL }}{{ .ProbeURL }}
+{{ end }}{{ end }}`
+
+// Maximum length of the description of a Discord embed.
+const discordEmbedLength = 4096
+
+// NewDiscordAlerter opens a bot session to post alerts in the configured channel.
+func NewDiscordAlerter(dc *DiscordConfiguration, mt *messageTemplate) (*DiscordAlerter, error) {
 	session, err := discordgo.New("Bot " + dc.Token)
 	if err != nil {
-		log.Printf("[WARNING] an error occured while trying to initialize session client. got: [%v]\n", err)
-		return nil
+		return nil, fmt.Errorf("an error occured while trying to initialize session client: %w", err)
+	}
+	da := newDiscordAlerter(dc.ChannelID, session, mt)
+	if dc.Commands {
+		da.commands = &discordCommands{guildID: dc.GuildID, roles: dc.CommandRoles}
 	}
+	session.AddHandler(da.onReactionAdd)
 	err = session.Open()
 	if err != nil {
-		log.Printf("[WARNING] could not open Websocket to communicate using the session client. got: [%v]\n", err)
-		return nil
+		return nil, fmt.Errorf("could not open Websocket to communicate using the session client: %w", err)
 	}
+	return da, nil
+}
+
+func newDiscordAlerter(channelID string, session *discordgo.Session, mt *messageTemplate) *DiscordAlerter {
 	return &DiscordAlerter{
-		channelID: dc.ChannelID,
+		channelID: channelID,
 		session:   session,
+		template:  mt,
+		messages:  make(map[string][]string),
+	}
+}
+
+// Reaction acknowledging the outages of an alert message.
+const discordAckEmoji = "✅"
+
+// Number of alert messages whose reactions are watched.
+const discordWatchedMessages = 256
+
+// Colors of the messages depending on the status of the probes.
+const (
+	discordRed    = 0xE74C3C
+	discordGreen  = 0x2ECC71
+	discordOrange = 0xE67E22
+)
+
+// Alert posts the notification in the channel as a rich embed
+// whose description is rendered from the receiver template.
+func (da *DiscordAlerter) Alert(notification Notification) error {
+	embed, err := discordEmbed(notification, da.template)
+	if err != nil {
+		return err
 	}
+	msg, err := da.session.ChannelMessageSendEmbed(da.channelID, embed)
+	if err != nil {
+		return err
+	}
+	da.watch(msg.ID, notification)
+	return nil
+}
+
+// OnAcknowledge registers the function called when a user reacts with ✅ on an alert message.
+func (da *DiscordAlerter) OnAcknowledge(acknowledge func(Acknowledgement) error) {
+	da.mu.Lock()
+	defer da.mu.Unlock()
+	da.acknowledge = acknowledge
 }
 
-func (da *DiscordAlerter) Alert(eventBus <-chan prober.Probe) {
-	var probe prober.Probe
-	for {
-		select {
-		case probe = <-eventBus:
-			msg := fmt.Sprintf("Probe [%s] is currently [%s]", probe.Name, probe.Status)
-			_, err := da.session.ChannelMessageSend(da.channelID, msg)
-			if err != nil {
-				fmt.Println(err)
-			}
-		default:
-			time.Sleep(time.Duration(probe.Delay) * time.Second)
+// watch remembers the DOWN probes of an alert message and adds the ✅ reaction users can click.
+// Only the last messages are remembered.
+func (da *DiscordAlerter) watch(messageID string, notification Notification) {
+	var probes []string
+	for _, event := range notification.Events {
+		if event.Probe.Status == prober.DownStatus {
+			probes = append(probes, event.Probe.Name)
+		}
+	}
+	if len(probes) == 0 {
+		return
+	}
+	da.mu.Lock()
+	da.messages[messageID] = probes
+	da.sent = append(da.sent, messageID)
+	if len(da.sent) > discordWatchedMessages {
+		delete(da.messages, da.sent[0])
+		da.sent = da.sent[1:]
+	}
+	da.mu.Unlock()
+	if err := da.session.MessageReactionAdd(da.channelID, messageID, discordAckEmoji); err != nil {
+		log.Printf("[WARNING] could not add acknowledgement reaction to message [%s]. got: [%v]\n", messageID, err)
+	}
+}
+
+// onReactionAdd acknowledges the DOWN probes of an alert message when a user reacts with ✅.
+// Reactions of the bot itself are ignored.
+func (da *DiscordAlerter) onReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.Emoji.Name != discordAckEmoji || (s.State != nil && s.State.User != nil && r.UserID == s.State.User.ID) {
+		return
+	}
+	da.mu.Lock()
+	probes := da.messages[r.MessageID]
+	acknowledge := da.acknowledge
+	da.mu.Unlock()
+	if acknowledge == nil {
+		return
+	}
+	for _, probe := range probes {
+		err := acknowledge(Acknowledgement{
+			Probe:   probe,
+			By:      "discord:" + r.UserID,
+			Comment: "acknowledged with a reaction in Discord",
+		})
+		if err != nil && err != ErrProbeNotDown {
+			log.Printf("[WARNING] could not acknowledge probe [%s]. got: [%v]\n", probe, err)
 		}
 	}
 }
@@ -49,3 +134,67 @@ func (da *DiscordAlerter) Alert(eventBus <-chan prober.Probe) {
 func (da *DiscordAlerter) Close() error {
 	return da.session.Close()
 }
+
+// discordEmbed titles the embed after the status change of a single probe
+// or the number of probes of a group, and colors it after the worst status.
+func discordEmbed(notification Notification, mt *messageTemplate) (*discordgo.MessageEmbed, error) {
+	description, err := mt.render(notification)
+	if err != nil {
+		return nil, err
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       notificationTitle(notification),
+		Description: truncate(description, discordEmbedLength),
+		Color:       notificationColor(notification),
+	}
+	if len(notification.Events) != 1 {
+		return embed, nil
+	}
+	event := notification.Events[0]
+	embed.Timestamp = event.Time.Format(time.RFC3339)
+	if strings.HasPrefix(event.Probe.URL, "http") {
+		embed.URL = event.Probe.URL
+	}
+	return embed, nil
+}
+
+// notificationTitle announces the status change of a single probe or counts the probes of a group.
+func notificationTitle(notification Notification) string {
+	if len(notification.Events) != 1 {
+		if notification.Repeat {
+			return fmt.Sprintf("%d probes are still %s", len(notification.Events), prober.DownStatus)
+		}
+		return fmt.Sprintf("%d probes changed status", len(notification.Events))
+	}
+	event := notification.Events[0]
+	probe := event.Probe
+	switch {
+	case notification.Repeat:
+		return fmt.Sprintf("Probe [%s] is still %s", probe.Name, probe.Status)
+	case event.PreviousStatus == prober.DownStatus && probe.Status == prober.UpStatus:
+		return fmt.Sprintf("Probe [%s] recovered", probe.Name)
+	}
+	return fmt.Sprintf("Probe [%s] is %s", probe.Name, probe.Status)
+}
+
+// notificationColor returns the color of the worst status of the notification.
+func notificationColor(notification Notification) int {
+	color := discordGreen
+	for _, event := range notification.Events {
+		if c := discordColor(event.Probe.Status); c != discordGreen && color != discordRed {
+			color = c
+		}
+	}
+	return color
+}
+
+// discordColor returns red for DOWN probes, green for UP probes and orange otherwise.
+func discordColor(status string) int {
+	switch status {
+	case prober.DownStatus:
+		return discordRed
+	case prober.UpStatus:
+		return discordGreen
+	}
+	return discordOrange
+}
@@ -0,0 +1,309 @@
+package alerter
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Name of the slash command managing probes.
+const discordCommandName = "probe"
+
+// Maximum length of a Discord message.
+const discordMessageLength = 2000
+
+// Error returned when a user without any of the command roles runs a command changing probes.
+var errDiscordForbidden = errors.New("you are not allowed to change probes")
+
+// Commander is implemented by the alerters letting their users manage probes.
+type Commander interface {
+	ServeCommands(probes prober.ProbeService, silences silencer.SilenceService) error
+}
+
+// discordCommands answers the /probe slash commands of a bot.
+type discordCommands struct {
+	// Commands are registered in this server only when set, globally otherwise.
+	guildID string
+	// Roles allowed to run the commands changing probes.
+	roles    []string
+	probes   prober.ProbeService
+	silences silencer.SilenceService
+}
+
+// discordCommand describes the /probe command and its subcommands.
+var discordCommand = &discordgo.ApplicationCommand{
+	Name:        discordCommandName,
+	Description: "Manage the probes of madprobe",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "List the probes and their status",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "status",
+			Description: "Show the status of a probe",
+			Options:     []*discordgo.ApplicationCommandOption{discordNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "pause",
+			Description: "Stop checking a probe until it is resumed",
+			Options:     []*discordgo.ApplicationCommandOption{discordNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "resume",
+			Description: "Check a paused probe again",
+			Options:     []*discordgo.ApplicationCommandOption{discordNameOption},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "silence",
+			Description: "Mute the alerts of a probe for a while",
+			Options: []*discordgo.ApplicationCommandOption{
+				discordNameOption,
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duration",
+					Description: "How long the probe is silenced, e.g. 1h or 30m",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "comment",
+					Description: "Why the probe is silenced",
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "create",
+			Description: "Create a new probe",
+			Options: []*discordgo.ApplicationCommandOption{
+				discordNameOption,
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "URL checked by the probe",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "delay",
+					Description: "Seconds between two checks",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "severity",
+					Description: "Severity of the probe alerts",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: prober.CriticalSeverity, Value: prober.CriticalSeverity},
+						{Name: prober.WarningSeverity, Value: prober.WarningSeverity},
+						{Name: prober.InfoSeverity, Value: prober.InfoSeverity},
+					},
+				},
+			},
+		},
+	},
+}
+
+var discordNameOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "name",
+	Description: "Name of the probe",
+	Required:    true,
+}
+
+// Subcommands changing probes, restricted to the command roles.
+var discordMutatingCommands = map[string]bool{"pause": true, "resume": true, "silence": true, "create": true}
+
+// ServeCommands registers the /probe slash command of the bot and answers it
+// with the given services. It does nothing unless commands are enabled for the receiver.
+func (da *DiscordAlerter) ServeCommands(probes prober.ProbeService, silences silencer.SilenceService) error {
+	if da.commands == nil {
+		return nil
+	}
+	da.commands.probes = probes
+	da.commands.silences = silences
+	if da.session.State == nil || da.session.State.User == nil {
+		return errors.New("bot user is unknown, commands can't be registered")
+	}
+	_, err := da.session.ApplicationCommandBulkOverwrite(da.session.State.User.ID, da.commands.guildID, []*discordgo.ApplicationCommand{discordCommand})
+	if err != nil {
+		return fmt.Errorf("could not register the /%s command: %w", discordCommandName, err)
+	}
+	da.session.AddHandler(da.onInteractionCreate)
+	return nil
+}
+
+// onInteractionCreate answers the /probe commands, errors are only shown to the user who ran the command.
+func (da *DiscordAlerter) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand || i.ApplicationCommandData().Name != discordCommandName {
+		return
+	}
+	data := &discordgo.InteractionResponseData{}
+	content, err := da.commands.answer(i.Interaction)
+	if err != nil {
+		content = err.Error()
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	data.Content = truncate(content, discordMessageLength)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		log.Printf("[WARNING] could not answer the /%s command. got: [%v]\n", discordCommandName, err)
+	}
+}
+
+// answer runs the subcommand of the interaction and returns the message answering it.
+func (dc *discordCommands) answer(i *discordgo.Interaction) (string, error) {
+	options := i.ApplicationCommandData().Options
+	if len(options) != 1 {
+		return "", errors.New("a subcommand is required")
+	}
+	subcommand := options[0]
+	if discordMutatingCommands[subcommand.Name] && !dc.allowed(i.Member) {
+		return "", errDiscordForbidden
+	}
+	values := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, option := range subcommand.Options {
+		values[option.Name] = option
+	}
+	str := func(name string) string {
+		if option, ok := values[name]; ok && option.Type == discordgo.ApplicationCommandOptionString {
+			return option.StringValue()
+		}
+		return ""
+	}
+
+	switch subcommand.Name {
+	case "list":
+		return dc.list()
+	case "status":
+		return dc.status(str("name"))
+	case "pause":
+		if err := dc.probes.Pause(str("name")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Probe [%s] has been paused.", str("name")), nil
+	case "resume":
+		if err := dc.probes.Resume(str("name")); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Probe [%s] has been resumed.", str("name")), nil
+	case "silence":
+		return dc.silence(str("name"), str("duration"), str("comment"), i)
+	case "create":
+		var delay int64
+		if option, ok := values["delay"]; ok && option.Type == discordgo.ApplicationCommandOptionInteger {
+			delay = option.IntValue()
+		}
+		if delay <= 0 {
+			return "", errors.New("delay must be at least 1 and strictly positive")
+		}
+		probe := prober.NewProbe(str("name"), str("url"), uint(delay))
+		probe.Severity = str("severity")
+		if err := dc.probes.Insert(*probe); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Probe [%s] has been created.", probe.Name), nil
+	}
+	return "", fmt.Errorf("unknown subcommand [%s]", subcommand.Name)
+}
+
+// allowed tells if the member has one of the command roles.
+// Commands run outside of a server have no member and are never allowed.
+func (dc *discordCommands) allowed(member *discordgo.Member) bool {
+	if member == nil {
+		return false
+	}
+	for _, role := range member.Roles {
+		for _, allowed := range dc.roles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (dc *discordCommands) list() (string, error) {
+	probes, err := dc.probes.GetAll()
+	if err != nil {
+		return "", err
+	}
+	if len(probes) == 0 {
+		return "No probe has been created.", nil
+	}
+	var lines []string
+	for _, probe := range probes {
+		lines = append(lines, fmt.Sprintf("**%s** %s - %s", markdownReplacer.Replace(probe.Name), discordProbeStatus(probe), probe.URL))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (dc *discordCommands) status(name string) (string, error) {
+	probe, err := dc.probes.Get(name)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{
+		fmt.Sprintf("**%s** is %s", markdownReplacer.Replace(probe.Name), discordProbeStatus(probe)),
+		"URL: " + probe.URL,
+		"Severity: " + probe.EffectiveSeverity(),
+	}
+	if !probe.Since.IsZero() {
+		lines = append(lines, "Since: "+probe.Since.Format(time.RFC1123))
+	}
+	if probe.LastError != "" {
+		lines = append(lines, "Last error: "+probe.LastError)
+	}
+	for _, silence := range dc.silences.Active(*probe) {
+		lines = append(lines, "Silenced by: "+silence.Name)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// silence mutes the alerts of the probe from now on for the given duration.
+func (dc *discordCommands) silence(name, duration, comment string, i *discordgo.Interaction) (string, error) {
+	if _, err := dc.probes.Get(name); err != nil {
+		return "", err
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf("duration [%s] is not a valid duration", duration)
+	}
+	now := time.Now()
+	silence := silencer.NewSilence(fmt.Sprintf("%s-%d", name, now.Unix()), regexp.QuoteMeta(name), now, now.Add(d))
+	silence.Comment = comment
+	if silence.Comment == "" && i.Member != nil && i.Member.User != nil {
+		silence.Comment = "silenced in Discord by " + i.Member.User.Username
+	}
+	if err := dc.silences.Insert(*silence); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Probe [%s] is silenced until %s by [%s].", name, silence.EndsAt.Format(time.RFC1123), silence.Name), nil
+}
+
+// discordProbeStatus returns the status of the probe, telling whether it is paused.
+func discordProbeStatus(probe *prober.Probe) string {
+	status := probe.Status
+	if status == "" {
+		status = "PENDING"
+	}
+	if probe.Paused() {
+		status += " (paused)"
+	}
+	return status
+}
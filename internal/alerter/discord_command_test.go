@@ -0,0 +1,161 @@
+package alerter
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fake of the interface ProbeService holding probes in memory.
+type fakeProbes struct {
+	probes map[string]*prober.Probe
+}
+
+func (f *fakeProbes) Insert(probe prober.Probe) error {
+	if _, ok := f.probes[probe.Name]; ok {
+		return prober.ErrProbeAlreadyExist
+	}
+	f.probes[probe.Name] = &probe
+	return nil
+}
+
+func (f *fakeProbes) Get(name string) (*prober.Probe, error) {
+	if probe, ok := f.probes[name]; ok {
+		return probe, nil
+	}
+	return nil, prober.ErrProbeNotFound
+}
+
+func (f *fakeProbes) GetAll() ([]*prober.Probe, error) {
+	var probes []*prober.Probe
+	for _, probe := range f.probes {
+		probes = append(probes, probe)
+	}
+	return probes, nil
+}
+
+func (f *fakeProbes) Delete(name string) error {
+	delete(f.probes, name)
+	return nil
+}
+
+func (f *fakeProbes) Pause(name string) error {
+	probe, err := f.Get(name)
+	if err != nil {
+		return err
+	}
+	probe.SetPaused(true)
+	return nil
+}
+
+func (f *fakeProbes) Resume(name string) error {
+	probe, err := f.Get(name)
+	if err != nil {
+		return err
+	}
+	probe.SetPaused(false)
+	return nil
+}
+
+// newTestInteraction builds a /probe interaction running the subcommand with the given options.
+func newTestInteraction(roles []string, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.Interaction {
+	return &discordgo.Interaction{
+		Type:   discordgo.InteractionApplicationCommand,
+		Member: &discordgo.Member{Roles: roles, User: &discordgo.User{Username: "jdoe"}},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: discordCommandName,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: subcommand, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options},
+			},
+		},
+	}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func newTestDiscordCommands() (*discordCommands, *fakeProbes, *fakeSilences) {
+	api := prober.NewProbe("api", "http://localhost/health", 5)
+	api.Status = prober.DownStatus
+	api.LastError = "connection refused"
+	probes := &fakeProbes{probes: map[string]*prober.Probe{"api": api}}
+	silences := &fakeSilences{silenced: map[string]bool{"api": true}}
+	return &discordCommands{roles: []string{"ops"}, probes: probes, silences: silences}, probes, silences
+}
+
+func TestDiscordCommandsReadProbes(t *testing.T) {
+	dc, _, _ := newTestDiscordCommands()
+
+	content, err := dc.answer(newTestInteraction(nil, "list"))
+	if err != nil || content != "**api** DOWN - http://localhost/health" {
+		t.Errorf("list should show the probes to anyone. got: %s %v\n", content, err)
+	}
+	content, err = dc.answer(newTestInteraction(nil, "status", stringOption("name", "api")))
+	if err != nil || !strings.Contains(content, "Last error: connection refused") || !strings.Contains(content, "Silenced by: fake") {
+		t.Errorf("status should detail the probe. got: %s %v\n", content, err)
+	}
+	if _, err := dc.answer(newTestInteraction(nil, "status", stringOption("name", "unknown"))); err != prober.ErrProbeNotFound {
+		t.Errorf("status of an unknown probe should fail. got: %v\n", err)
+	}
+}
+
+func TestDiscordCommandsRestrictMutations(t *testing.T) {
+	dc, probes, _ := newTestDiscordCommands()
+
+	if _, err := dc.answer(newTestInteraction([]string{"dev"}, "pause", stringOption("name", "api"))); err != errDiscordForbidden {
+		t.Errorf("members without a command role should not pause probes. got: %v\n", err)
+	}
+	interaction := newTestInteraction(nil, "pause", stringOption("name", "api"))
+	interaction.Member = nil
+	if _, err := dc.answer(interaction); err != errDiscordForbidden {
+		t.Errorf("commands run outside of a server should not pause probes. got: %v\n", err)
+	}
+	if probes.probes["api"].Paused() {
+		t.Fatal("probe should not have been paused")
+	}
+
+	if _, err := dc.answer(newTestInteraction([]string{"dev", "ops"}, "pause", stringOption("name", "api"))); err != nil {
+		t.Fatalf("members with a command role should pause probes. got: %v\n", err)
+	}
+	content, _ := dc.answer(newTestInteraction(nil, "list"))
+	if !probes.probes["api"].Paused() || !strings.Contains(content, "DOWN (paused)") {
+		t.Errorf("probe should be paused. got: %s\n", content)
+	}
+	if _, err := dc.answer(newTestInteraction([]string{"ops"}, "resume", stringOption("name", "api"))); err != nil || probes.probes["api"].Paused() {
+		t.Errorf("probe should be resumed. got: %v\n", err)
+	}
+}
+
+func TestDiscordCommandsSilenceProbe(t *testing.T) {
+	dc, _, silences := newTestDiscordCommands()
+
+	if _, err := dc.answer(newTestInteraction([]string{"ops"}, "silence", stringOption("name", "api"), stringOption("duration", "forever"))); err == nil {
+		t.Error("silence should require a valid duration")
+	}
+	_, err := dc.answer(newTestInteraction([]string{"ops"}, "silence", stringOption("name", "api"), stringOption("duration", "1h")))
+	if err != nil || len(silences.inserted) != 1 {
+		t.Fatalf("a silence should have been created. got: %v\n", err)
+	}
+	silence := silences.inserted[0]
+	if silence.Probe != "api" || silence.EndsAt.Sub(silence.StartsAt) != time.Hour || silence.Comment != "silenced in Discord by jdoe" {
+		t.Errorf("silence should mute the probe for an hour. got: %+v\n", silence)
+	}
+}
+
+func TestDiscordCommandsCreateProbe(t *testing.T) {
+	dc, probes, _ := newTestDiscordCommands()
+
+	delay := &discordgo.ApplicationCommandInteractionDataOption{Name: "delay", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(10)}
+	_, err := dc.answer(newTestInteraction([]string{"ops"}, "create",
+		stringOption("name", "db"), stringOption("url", "http://localhost/db"), delay, stringOption("severity", prober.WarningSeverity)))
+	if err != nil {
+		t.Fatalf("probe should have been created. got: %v\n", err)
+	}
+	probe := probes.probes["db"]
+	if probe == nil || probe.URL != "http://localhost/db" || probe.Delay != 10 || probe.Severity != prober.WarningSeverity {
+		t.Errorf("probe should be created with the options of the command. got: %+v\n", probe)
+	}
+}
@@ -0,0 +1,114 @@
+package alerter
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func newTestDiscordTemplate(t *testing.T) *messageTemplate {
+	mt, err := newMessageTemplate("discord", discordTemplate, "")
+	if err != nil {
+		t.Fatalf("default discord template should be valid. got: %v\n", err)
+	}
+	return mt
+}
+
+func TestDiscordEmbedOnRecovery(t *testing.T) {
+	now := time.Now()
+	probe := prober.NewProbe("api", "http://localhost/health", 5)
+	probe.Status = prober.UpStatus
+	probe.Since = now.Add(-10 * time.Minute)
+	probe.StatusCode = 200
+	probe.LastError = "connection refused"
+
+	notification := Notification{Events: []prober.Event{prober.NewEvent(*probe, prober.DownStatus, now)}}
+	embed, err := discordEmbed(notification, newTestDiscordTemplate(t))
+	if err != nil {
+		t.Fatalf("embed should be rendered. got: %v\n", err)
+	}
+	if embed.Title != "Probe [api] recovered" {
+		t.Errorf("embed title should announce the recovery. got: %s\n", embed.Title)
+	}
+	if embed.Color != discordGreen {
+		t.Errorf("embed color should be green. got: %x\n", embed.Color)
+	}
+	expected := "**api** recovered after 10m0s of outage - http://localhost/health\n> Last error: connection refused"
+	if embed.Description != expected {
+		t.Errorf("embed description should detail the recovery. got: %s\n", embed.Description)
+	}
+}
+
+func TestDiscordEmbedSummarizesGroups(t *testing.T) {
+	now := time.Now()
+	up := prober.NewProbe("a", "http://localhost/a", 5)
+	up.Status = prober.UpStatus
+	down := prober.NewProbe("b", "http://localhost/b", 5)
+	down.Status = prober.DownStatus
+	down.LastError = "timeout"
+
+	notification := Notification{Events: []prober.Event{
+		prober.NewEvent(*up, "", now),
+		prober.NewEvent(*down, prober.UpStatus, now),
+	}}
+	embed, err := discordEmbed(notification, newTestDiscordTemplate(t))
+	if err != nil {
+		t.Fatalf("embed should be rendered. got: %v\n", err)
+	}
+	if embed.Title != "2 probes changed status" {
+		t.Errorf("embed title should count the probes. got: %s\n", embed.Title)
+	}
+	if embed.Color != discordRed {
+		t.Errorf("embed color should be red when a probe is down. got: %x\n", embed.Color)
+	}
+	expected := "**a** is UP - http://localhost/a\n**b** is DOWN - http://localhost/b\n> timeout"
+	if embed.Description != expected {
+		t.Errorf("embed description should list the probes. got: %s\n", embed.Description)
+	}
+}
+
+func TestDiscordEmbedIsTruncated(t *testing.T) {
+	probe := prober.NewProbe("api", "http://localhost/health", 5)
+	probe.Status = prober.DownStatus
+	probe.LastError = strings.Repeat("é", 5000)
+
+	notification := Notification{Events: []prober.Event{prober.NewEvent(*probe, prober.UpStatus, time.Now())}}
+	embed, err := discordEmbed(notification, newTestDiscordTemplate(t))
+	if err != nil {
+		t.Fatalf("embed should be rendered. got: %v\n", err)
+	}
+	if length := utf8.RuneCountInString(embed.Description); length != discordEmbedLength || !strings.HasSuffix(embed.Description, "…") {
+		t.Errorf("embed description should be truncated to the Discord limit. got: %d characters\n", length)
+	}
+}
+
+func TestDiscordReactionAcknowledgesDownProbes(t *testing.T) {
+	da := newDiscordAlerter("channel", nil, newTestDiscordTemplate(t))
+	da.messages["alert"] = []string{"api", "db"}
+	var acks []Acknowledgement
+	da.OnAcknowledge(func(ack Acknowledgement) error {
+		acks = append(acks, ack)
+		return nil
+	})
+	session := &discordgo.Session{State: discordgo.NewState()}
+	session.State.User = &discordgo.User{ID: "bot"}
+	react := func(userID, messageID, emoji string) {
+		da.onReactionAdd(session, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+			UserID: userID, MessageID: messageID, Emoji: discordgo.Emoji{Name: emoji},
+		}})
+	}
+
+	react("bot", "alert", discordAckEmoji)
+	react("user", "alert", "👀")
+	react("user", "unknown", discordAckEmoji)
+	if len(acks) != 0 {
+		t.Fatalf("only the ✅ reactions of users on alert messages should acknowledge. got: %+v\n", acks)
+	}
+	react("user", "alert", discordAckEmoji)
+	if len(acks) != 2 || acks[0].Probe != "api" || acks[1].Probe != "db" || acks[0].By != "discord:user" {
+		t.Errorf("probes of the message should be acknowledged by the user. got: %+v\n", acks)
+	}
+}
@@ -0,0 +1,261 @@
+package alerter
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default template of the email subject.
+const emailSubjectTemplate = `[{{ .Status }}]{{ if .Repeat }} Reminder:{{ end }} {{ range $i, $alert := .Alerts }}{{ if $i }}, {{ end }}{{ $alert.Name }}{{ end }}`
+
+// Default template of the plain text body of the emails.
+const emailTemplate = `{{ range .Alerts -}}
+{{ .Name }} {{ if .Recovered }}recovered after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }}is still {{ .Status }} for {{ duration .OutageDuration }}{{ else }}is {{ .Status }}{{ end }}
+{{ if .URL }}  URL: {{ .URL }}
+{{ end }}{{ if .LastError }}  Last error: {{ .LastError }}
+{{ end }}  Changed at: {{ date .Time }}
+{{ if .ProbeURL }}  Details: {{ .ProbeURL }}
+{{ end }}
+{{ end }}`
+
+// Default template of the HTML body of the emails.
+const emailHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr><th align="left">Probe</th><th align="left">Status</th><th align="left">Since</th><th align="left">Last error</th></tr>
+{{ range .Alerts -}}
+<tr>
+<td>{{ if .ProbeURL }}<a href="{{ .ProbeURL }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}<br><small>{{ .URL }}</small></td>
+<td style="color: {{ if eq .Status "DOWN" }}#E74C3C{{ else if eq .Status "UP" }}#2ECC71{{ else }}#E67E22{{ end }};"><b>{{ .Status }}</b>{{ if .Recovered }} after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }} for {{ duration .OutageDuration }}{{ end }}</td>
+<td>{{ date .Time }}</td>
+<td>{{ .LastError }}</td>
+</tr>
+{{ end -}}
+</table>
+</body>
+</html>`
+
+// Maximum duration of a conversation with the SMTP server.
+const emailTimeout = 30 * time.Second
+
+// NewEmailAlerter prepares the templates of the emails sent through the configured SMTP server.
+// The receiver template renders the plain text body.
+func NewEmailAlerter(ec *EmailConfiguration, mt *messageTemplate) (*EmailAlerter, error) {
+	subject, err := newMessageTemplate("subject", ec.subject(), mt.externalURL)
+	if err != nil {
+		return nil, err
+	}
+	html, err := newHTMLMessageTemplate("html", ec.htmlTemplate(), mt.externalURL)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailAlerter{
+		config:  ec,
+		text:    mt,
+		subject: subject,
+		html:    html,
+		tlsConfig: &tls.Config{
+			ServerName:         ec.Host,
+			InsecureSkipVerify: ec.InsecureSkipVerify,
+		},
+	}, nil
+}
+
+// Alert sends the notification to the recipients of the route,
+// or to the default recipients of the receiver when the route has none.
+func (ea *EmailAlerter) Alert(notification Notification) error {
+	to := notification.Recipients
+	if len(to) == 0 {
+		to = ea.config.To
+	}
+	if len(to) == 0 {
+		return errors.New("email has no recipient")
+	}
+	msg, err := ea.message(notification, to)
+	if err != nil {
+		return err
+	}
+	return ea.send(to, msg)
+}
+
+// message builds a multipart/alternative email holding the plain text and the HTML bodies.
+func (ea *EmailAlerter) message(notification Notification, to []string) ([]byte, error) {
+	subject, err := ea.subject.render(notification)
+	if err != nil {
+		return nil, err
+	}
+	text, err := ea.text.render(notification)
+	if err != nil {
+		return nil, err
+	}
+	html, err := ea.html.render(notification)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	body := multipart.NewWriter(&msg)
+	header := []string{
+		"From: " + ea.config.From,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+	msg.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	if err := writePart(body, "text/plain; charset=utf-8", text); err != nil {
+		return nil, err
+	}
+	if err := writePart(body, "text/html; charset=utf-8", html); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func writePart(body *multipart.Writer, contentType, content string) error {
+	part, err := body.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// send delivers the message to the SMTP server, securing the connection and authenticating as configured.
+func (ea *EmailAlerter) send(to []string, msg []byte) error {
+	ec := ea.config
+	addr := net.JoinHostPort(ec.Host, strconv.Itoa(ec.Port))
+	dialer := &net.Dialer{Timeout: emailTimeout}
+	var conn net.Conn
+	var err error
+	if ec.Security == TLSSecurity {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, ea.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("could not connect to SMTP server [%s]: %w", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(emailTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, ec.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("could not talk to SMTP server [%s]: %w", addr, err)
+	}
+	defer c.Close()
+
+	if ec.Security == StartTLSSecurity {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server [%s] doesn't support STARTTLS", addr)
+		}
+		if err := c.StartTLS(ea.tlsConfig); err != nil {
+			return err
+		}
+	}
+	if auth := ea.auth(); auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("could not authenticate to SMTP server [%s]: %w", addr, err)
+		}
+	}
+
+	from, err := mail.ParseAddress(ec.From)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("email recipient [%s] is invalid: %w", recipient, err)
+		}
+		if err := c.Rcpt(address.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (ea *EmailAlerter) auth() smtp.Auth {
+	ec := ea.config
+	switch ec.Auth {
+	case PlainAuth:
+		return smtp.PlainAuth("", ec.Username, ec.Password, ec.Host)
+	case LoginAuth:
+		return &loginAuth{username: ec.Username, password: ec.Password, host: ec.Host}
+	}
+	return nil
+}
+
+// loginAuth implements the LOGIN authentication mechanism, which the standard library lacks.
+// Like smtp.PlainAuth, it only sends the credentials over TLS or to localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge [%s]", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,305 @@
+package alerter
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMail is a message received by the fake SMTP server.
+type fakeMail struct {
+	auth string
+	tls  bool
+	from string
+	to   []string
+	data []byte
+}
+
+// fakeSMTPServer is a minimal in-process SMTP server supporting STARTTLS and the PLAIN and LOGIN mechanisms.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	roots     *x509.CertPool
+	implicit  bool
+	mails     chan fakeMail
+}
+
+func newFakeSMTPServer(t *testing.T, implicit bool) *fakeSMTPServer {
+	// httptest generates a certificate valid for 127.0.0.1 and the pool trusting it.
+	https := httptest.NewUnstartedServer(http.NotFoundHandler())
+	https.StartTLS()
+	tlsConfig := &tls.Config{Certificates: https.TLS.Certificates}
+	roots := https.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	https.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fake SMTP server should listen. got: %v\n", err)
+	}
+	if implicit {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	s := &fakeSMTPServer{listener: listener, tlsConfig: tlsConfig, roots: roots, implicit: implicit, mails: make(chan fakeMail, 4)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) close() {
+	s.listener.Close()
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	tp := textproto.NewConn(conn)
+	mail := fakeMail{tls: s.implicit}
+	tp.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " ")[0])
+		arg := strings.TrimSpace(line[len(verb):])
+		switch verb {
+		case "EHLO":
+			extensions := "250-fake\r\n250-AUTH PLAIN LOGIN\r\n"
+			if !mail.tls {
+				extensions += "250-STARTTLS\r\n"
+			}
+			tp.PrintfLine("%s250 8BITMIME", extensions)
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, tp, mail.tls = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			mail.auth = s.authenticate(tp, arg)
+			tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			mail.from = path(arg, "FROM:")
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			mail.to = append(mail.to, path(arg, "TO:"))
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = data
+			tp.PrintfLine("250 queued")
+			s.mails <- mail
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unsupported")
+		}
+	}
+}
+
+// path extracts the address of a MAIL or RCPT command, ignoring its parameters.
+func path(arg, prefix string) string {
+	return strings.Trim(strings.Fields(strings.TrimPrefix(arg, prefix))[0], "<>")
+}
+
+// authenticate returns the mechanism and the credentials sent by the client, e.g. "PLAIN user:password".
+func (s *fakeSMTPServer) authenticate(tp *textproto.Conn, arg string) string {
+	decode := func(value string) string {
+		decoded, _ := base64.StdEncoding.DecodeString(value)
+		return string(decoded)
+	}
+	fields := strings.Fields(arg)
+	if fields[0] == "PLAIN" {
+		credentials := strings.Split(decode(fields[1]), "\x00")
+		return "PLAIN " + credentials[1] + ":" + credentials[2]
+	}
+	tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+	username, _ := tp.ReadLine()
+	tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+	password, _ := tp.ReadLine()
+	return "LOGIN " + decode(username) + ":" + decode(password)
+}
+
+func (s *fakeSMTPServer) receive(t *testing.T) fakeMail {
+	select {
+	case mail := <-s.mails:
+		return mail
+	case <-time.After(5 * time.Second):
+		t.Fatal("an email should have been received")
+	}
+	return fakeMail{}
+}
+
+func newTestEmailAlerter(t *testing.T, s *fakeSMTPServer, ec *EmailConfiguration) *EmailAlerter {
+	ec.Host = "127.0.0.1"
+	ec.Port = s.port()
+	ec.From = "Madprobe <madprobe@example.com>"
+	if err := ec.validate("email"); err != nil {
+		t.Fatalf("email configuration should be valid. got: %v\n", err)
+	}
+	mt, err := newMessageTemplate("email", emailTemplate, "https://madprobe.example.com")
+	if err != nil {
+		t.Fatalf("default email template should be valid. got: %v\n", err)
+	}
+	ea, err := NewEmailAlerter(ec, mt)
+	if err != nil {
+		t.Fatalf("email alerter should be created. got: %v\n", err)
+	}
+	ea.tlsConfig.RootCAs = s.roots
+	return ea
+}
+
+func newTestEmailNotification() Notification {
+	probe := prober.NewProbe("api", "http://localhost/health", 5)
+	probe.Status = prober.DownStatus
+	probe.LastError = "connection refused"
+	return Notification{Receiver: "email", Events: []prober.Event{prober.NewEvent(*probe, prober.UpStatus, time.Now())}}
+}
+
+// parts returns the decoded bodies of the multipart email by content type.
+func parts(t *testing.T, msg *mail.Message) map[string]string {
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("email content type should be valid. got: %v\n", err)
+	}
+	bodies := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			break
+		}
+		body, _ := ioutil.ReadAll(quotedprintable.NewReader(part))
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+	return bodies
+}
+
+func TestEmailAlertStartTLSWithPlainAuth(t *testing.T) {
+	s := newFakeSMTPServer(t, false)
+	defer s.close()
+	ea := newTestEmailAlerter(t, s, &EmailConfiguration{
+		Auth:     PlainAuth,
+		Username: "user",
+		Password: "secret",
+		To:       []string{"ops@example.com"},
+	})
+	if err := ea.Alert(newTestEmailNotification()); err != nil {
+		t.Fatalf("email should be sent. got: %v\n", err)
+	}
+
+	received := s.receive(t)
+	if !received.tls || received.auth != "PLAIN user:secret" {
+		t.Errorf("email should be sent over STARTTLS with PLAIN auth. got: tls=%t auth=%s\n", received.tls, received.auth)
+	}
+	if received.from != "madprobe@example.com" || strings.Join(received.to, ",") != "ops@example.com" {
+		t.Errorf("email should be sent from madprobe to ops. got: %s -> %v\n", received.from, received.to)
+	}
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(received.data))))
+	if err != nil {
+		t.Fatalf("email should be well formed. got: %v\n", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "[DOWN] api" {
+		t.Errorf("email subject should summarize the alert. got: %s\n", subject)
+	}
+	bodies := parts(t, msg)
+	if !strings.Contains(bodies["text/plain"], "api is DOWN") || !strings.Contains(bodies["text/plain"], "Last error: connection refused") {
+		t.Errorf("plain text body should detail the alert. got: %s\n", bodies["text/plain"])
+	}
+	if !strings.Contains(bodies["text/html"], `<a href="https://madprobe.example.com/api/v1/probe/api">api</a>`) {
+		t.Errorf("HTML body should link the probe. got: %s\n", bodies["text/html"])
+	}
+}
+
+func TestEmailAlertImplicitTLSWithLoginAuth(t *testing.T) {
+	s := newFakeSMTPServer(t, true)
+	defer s.close()
+	ea := newTestEmailAlerter(t, s, &EmailConfiguration{
+		Security: TLSSecurity,
+		Auth:     LoginAuth,
+		Username: "user",
+		Password: "secret",
+		To:       []string{"ops@example.com"},
+	})
+	if err := ea.Alert(newTestEmailNotification()); err != nil {
+		t.Fatalf("email should be sent. got: %v\n", err)
+	}
+	if received := s.receive(t); !received.tls || received.auth != "LOGIN user:secret" {
+		t.Errorf("email should be sent over TLS with LOGIN auth. got: tls=%t auth=%s\n", received.tls, received.auth)
+	}
+}
+
+func TestEmailAlertRouteRecipients(t *testing.T) {
+	s := newFakeSMTPServer(t, false)
+	defer s.close()
+	ea := newTestEmailAlerter(t, s, &EmailConfiguration{Security: NoSecurity, To: []string{"ops@example.com"}})
+	notification := newTestEmailNotification()
+	notification.Recipients = []string{"payments@example.com", "Oncall <oncall@example.com>"}
+	if err := ea.Alert(notification); err != nil {
+		t.Fatalf("email should be sent. got: %v\n", err)
+	}
+	received := s.receive(t)
+	if received.tls || received.auth != "" {
+		t.Errorf("email should be sent in clear without auth. got: tls=%t auth=%s\n", received.tls, received.auth)
+	}
+	if strings.Join(received.to, ",") != "payments@example.com,oncall@example.com" {
+		t.Errorf("route recipients should override the receiver ones. got: %v\n", received.to)
+	}
+}
+
+func TestEmailConfigurationValidate(t *testing.T) {
+	ec := &EmailConfiguration{Host: "smtp.example.com", From: "madprobe@example.com"}
+	if err := ec.validate("email"); err != nil {
+		t.Fatalf("email configuration should be valid. got: %v\n", err)
+	}
+	if ec.Security != StartTLSSecurity || ec.Port != 587 {
+		t.Errorf("email should default to STARTTLS on port 587. got: %s:%d\n", ec.Security, ec.Port)
+	}
+	invalid := []*EmailConfiguration{
+		{From: "madprobe@example.com"},
+		{Host: "smtp.example.com"},
+		{Host: "smtp.example.com", From: "madprobe@example.com", Security: "ssl"},
+		{Host: "smtp.example.com", From: "madprobe@example.com", Auth: PlainAuth},
+		{Host: "smtp.example.com", From: "madprobe@example.com", To: []string{"not an address"}},
+		{Host: "smtp.example.com", From: "madprobe@example.com", HTMLTemplate: "{{ .Unknown }}"},
+	}
+	for _, c := range invalid {
+		if err := c.validate("email"); err == nil {
+			t.Errorf("email configuration should be invalid: %+v\n", c)
+		}
+	}
+}
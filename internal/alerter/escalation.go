@@ -0,0 +1,159 @@
+package alerter
+
+import (
+	"encoding/json"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"log"
+	"time"
+)
+
+// Label of the probes naming their escalation policy.
+const EscalationLabel = "escalation"
+
+// How often the outages are checked for escalation.
+const escalationInterval = 10 * time.Second
+
+// escalate starts the escalation of a probe going DOWN with an escalation policy,
+// and notifies the recovery to the tiers already reached when it comes back.
+// The escalation of a probe that was already DOWN before a restart goes on from where it was.
+func (s *service) escalate(event prober.Event) {
+	name := event.Probe.Name
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[WARNING] escalation of probe [%s] can't be encoded. got: [%v]\n", name, err)
+		return
+	}
+
+	s.emu.Lock()
+	defer s.emu.Unlock()
+	entity, escalating := s.escalating[name]
+	if event.Probe.Status != prober.DownStatus {
+		if escalating {
+			s.resolve(entity, event)
+		}
+		return
+	}
+	policy := event.Probe.Labels[EscalationLabel]
+	if _, ok := s.policies[policy]; !ok {
+		return
+	}
+	if !escalating || entity.Policy != policy {
+		entity = &persistence.EscalationEntity{Probe: name, Policy: policy, Since: event.Time}
+		s.escalating[name] = entity
+	}
+	entity.Event = data
+	s.saveEscalation(entity)
+	s.escalateDue(entity, time.Now())
+}
+
+// resolve notifies the recovery of the probe to the receivers of the tiers already reached
+// and ends its escalation.
+func (s *service) resolve(entity *persistence.EscalationEntity, event prober.Event) {
+	delete(s.escalating, entity.Probe)
+	if err := s.escalations.DeleteEscalation(entity.Probe); err != nil {
+		log.Printf("[WARNING] escalation of probe [%s] could not be deleted. got: [%v]\n", entity.Probe, err)
+	}
+	policy, ok := s.policies[entity.Policy]
+	if !ok {
+		return
+	}
+	// The probe was DOWN even if it restarted since, incident alerters need it to resolve their incident.
+	event.PreviousStatus = prober.DownStatus
+	notified := make(map[string]bool)
+	for _, tier := range policy.Tiers[:reachedTiers(entity, policy)] {
+		for _, receiver := range tier.Receivers {
+			if !notified[receiver] {
+				notified[receiver] = true
+				s.notify(Notification{Receiver: receiver, Events: []prober.Event{event}})
+			}
+		}
+	}
+}
+
+// escalateDue notifies the tiers of the policy whose delay has elapsed since the probe went DOWN.
+// Acknowledged and silenced probes are not escalated.
+func (s *service) escalateDue(entity *persistence.EscalationEntity, now time.Time) {
+	policy, ok := s.policies[entity.Policy]
+	if !ok || entity.Tiers >= len(policy.Tiers) || s.acknowledged(entity.Probe) {
+		return
+	}
+	var event prober.Event
+	if err := json.Unmarshal(entity.Event, &event); err != nil {
+		log.Printf("[WARNING] escalation of probe [%s] can't be decoded. got: [%v]\n", entity.Probe, err)
+		return
+	}
+	if len(s.silences.Active(event.Probe)) > 0 {
+		return
+	}
+	escalated := false
+	for entity.Tiers < len(policy.Tiers) && !now.Before(entity.Since.Add(policy.Tiers[entity.Tiers].After)) {
+		for _, receiver := range policy.Tiers[entity.Tiers].Receivers {
+			s.notify(Notification{Receiver: receiver, Events: []prober.Event{event}})
+		}
+		entity.Tiers++
+		escalated = true
+		log.Printf("Probe [%s] has been escalated to tier %d of policy [%s].\n", entity.Probe, entity.Tiers, entity.Policy)
+	}
+	if escalated {
+		s.saveEscalation(entity)
+	}
+}
+
+// reachedTiers returns the number of tiers of the policy the escalation has reached.
+func reachedTiers(entity *persistence.EscalationEntity, policy EscalationPolicy) int {
+	if entity.Tiers > len(policy.Tiers) {
+		return len(policy.Tiers)
+	}
+	return entity.Tiers
+}
+
+// runEscalations escalates the due outages periodically until the service is closed.
+func (s *service) runEscalations() {
+	ticker := time.NewTicker(s.escalationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.emu.Lock()
+			for _, entity := range s.escalating {
+				s.escalateDue(entity, now)
+			}
+			s.emu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// restoreEscalations resumes the escalations of a previous run.
+// Escalations of policies that are no longer configured are dropped.
+func (s *service) restoreEscalations() {
+	entities, err := s.escalations.GetAllEscalations()
+	if err != nil {
+		log.Printf("[WARNING] escalations could not be restored. got: [%v]\n", err)
+		return
+	}
+	s.emu.Lock()
+	defer s.emu.Unlock()
+	for _, entity := range entities {
+		if _, ok := s.policies[entity.Policy]; !ok {
+			if err := s.escalations.DeleteEscalation(entity.Probe); err != nil {
+				log.Printf("[WARNING] escalation of probe [%s] could not be deleted. got: [%v]\n", entity.Probe, err)
+			}
+			continue
+		}
+		// The policy may have lost tiers since the escalation was saved.
+		entity.Tiers = reachedTiers(entity, s.policies[entity.Policy])
+		s.escalating[entity.Probe] = entity
+		s.mu.Lock()
+		s.down[entity.Probe] = true
+		s.mu.Unlock()
+	}
+}
+
+func (s *service) saveEscalation(entity *persistence.EscalationEntity) {
+	if err := s.escalations.InsertEscalation(entity); err != nil {
+		log.Printf("[WARNING] escalation of probe [%s] could not be saved. got: [%v]\n", entity.Probe, err)
+	}
+}
@@ -0,0 +1,199 @@
+package alerter
+
+import (
+	"encoding/json"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake of the interface EscalationPersister keeping the escalations in memory.
+type fakeEscalations struct {
+	mu          sync.Mutex
+	escalations map[string]persistence.EscalationEntity
+}
+
+func newFakeEscalations() *fakeEscalations {
+	return &fakeEscalations{escalations: make(map[string]persistence.EscalationEntity)}
+}
+
+func (f *fakeEscalations) InsertEscalation(entity *persistence.EscalationEntity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.escalations[entity.Probe] = *entity
+	return nil
+}
+
+func (f *fakeEscalations) GetAllEscalations() ([]*persistence.EscalationEntity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var all []*persistence.EscalationEntity
+	for _, entity := range f.escalations {
+		entity := entity
+		all = append(all, &entity)
+	}
+	return all, nil
+}
+
+func (f *fakeEscalations) DeleteEscalation(probe string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.escalations, probe)
+	return nil
+}
+
+var testEscalationPolicy = EscalationPolicy{Name: "payments", Tiers: []EscalationTier{
+	{Receivers: []string{"chat"}},
+	{After: 15 * time.Minute, Receivers: []string{"mail"}},
+	{After: 30 * time.Minute, Receivers: []string{"pager", "mail"}},
+}}
+
+// newTestEscalationService returns a service escalating with testEscalationPolicy,
+// whose receivers only queue their notifications.
+func newTestEscalationService(escalations *fakeEscalations) (*service, *fakeDeliveries) {
+	deliveries := newFakeDeliveries()
+	s := newService(make(chan prober.Event), &fakeSilences{}, deliveries, &Configuration{
+		Queue:              testQueueConfiguration,
+		EscalationPolicies: []EscalationPolicy{testEscalationPolicy},
+	})
+	s.escalations = escalations
+	for _, receiver := range []string{"chat", "mail", "pager"} {
+		s.addReceiver(receiver, newFakeAlerter(0), nil)
+	}
+	return s, deliveries
+}
+
+// queued returns the notifications queued for every receiver.
+func queued(t *testing.T, deliveries *fakeDeliveries) map[string][]Notification {
+	entities, _ := deliveries.GetAllDeliveries()
+	notifications := make(map[string][]Notification)
+	for _, entity := range entities {
+		var n Notification
+		if err := json.Unmarshal(entity.Notification, &n); err != nil {
+			t.Fatalf("queued notification should be decoded. got: %v\n", err)
+		}
+		notifications[entity.Receiver] = append(notifications[entity.Receiver], n)
+	}
+	return notifications
+}
+
+func newTestEscalatedEvent(status string) prober.Event {
+	event := newTestEvent("api", status)
+	event.Probe.Labels = map[string]string{EscalationLabel: testEscalationPolicy.Name}
+	return event
+}
+
+func TestEscalationNotifiesTiersUntilRecovery(t *testing.T) {
+	escalations := newFakeEscalations()
+	s, deliveries := newTestEscalationService(escalations)
+
+	down := newTestEscalatedEvent(prober.DownStatus)
+	s.escalate(down)
+	if notifications := queued(t, deliveries); len(notifications["chat"]) != 1 || len(notifications["mail"]) != 0 {
+		t.Fatalf("first tier should be notified right away. got: %+v\n", notifications)
+	}
+	entity := s.escalating["api"]
+	s.escalateDue(entity, down.Time.Add(20*time.Minute))
+	if notifications := queued(t, deliveries); len(notifications["mail"]) != 1 || len(notifications["pager"]) != 0 {
+		t.Fatalf("second tier should be notified after 15 minutes. got: %+v\n", notifications)
+	}
+	if saved := escalations.escalations["api"]; saved.Tiers != 2 || !saved.Since.Equal(down.Time) {
+		t.Errorf("escalation should be saved. got: %+v\n", saved)
+	}
+
+	s.escalate(newTestEscalatedEvent(prober.UpStatus))
+	notifications := queued(t, deliveries)
+	if len(notifications["chat"]) != 2 || len(notifications["mail"]) != 2 || len(notifications["pager"]) != 0 {
+		t.Fatalf("recovery should be notified to the tiers reached. got: %+v\n", notifications)
+	}
+	if recovery := notifications["mail"][1].Events[0]; recovery.Probe.Status != prober.UpStatus || recovery.PreviousStatus != prober.DownStatus {
+		t.Errorf("recovery should be notified as such. got: %+v\n", recovery)
+	}
+	if len(escalations.escalations) != 0 || len(s.escalating) != 0 {
+		t.Errorf("escalation should end on recovery. got: %+v\n", escalations.escalations)
+	}
+}
+
+func TestEscalationStopsWhenAcknowledged(t *testing.T) {
+	s, deliveries := newTestEscalationService(newFakeEscalations())
+
+	down := newTestEscalatedEvent(prober.DownStatus)
+	s.track(down)
+	s.escalate(down)
+	if err := s.Acknowledge(Acknowledgement{Probe: "api", By: "jane"}); err != nil {
+		t.Fatalf("down probe should be acknowledged. got: %v\n", err)
+	}
+	s.escalateDue(s.escalating["api"], down.Time.Add(time.Hour))
+	if notifications := queued(t, deliveries); len(notifications["mail"]) != 0 || len(notifications["pager"]) != 0 {
+		t.Errorf("acknowledged probe should not be escalated. got: %+v\n", notifications)
+	}
+}
+
+func TestEscalationIsRestored(t *testing.T) {
+	escalations := newFakeEscalations()
+	s, _ := newTestEscalationService(escalations)
+	down := newTestEscalatedEvent(prober.DownStatus)
+	s.track(down)
+	s.escalate(down)
+
+	restarted, deliveries := newTestEscalationService(escalations)
+	restarted.restoreEscalations()
+
+	// The probe is checked again after the restart, the escalation goes on from the first outage.
+	again := newTestEscalatedEvent(prober.DownStatus)
+	again.Time = down.Time.Add(5 * time.Minute)
+	restarted.escalate(again)
+	entity := restarted.escalating["api"]
+	if entity.Tiers != 1 || !entity.Since.Equal(down.Time) {
+		t.Errorf("escalation should keep its timer. got: %+v\n", entity)
+	}
+	if notifications := queued(t, deliveries); len(notifications["chat"]) != 0 {
+		t.Errorf("reached tiers should not be notified again. got: %+v\n", notifications)
+	}
+}
+
+func TestEscalationIsRestoredWithShortenedPolicy(t *testing.T) {
+	escalations := newFakeEscalations()
+	down := newTestEscalatedEvent(prober.DownStatus)
+	data, _ := json.Marshal(down)
+	// The escalation was saved while the policy had 5 tiers.
+	_ = escalations.InsertEscalation(&persistence.EscalationEntity{Probe: "api", Policy: testEscalationPolicy.Name, Since: down.Time, Tiers: 5, Event: data})
+
+	s, deliveries := newTestEscalationService(escalations)
+	s.restoreEscalations()
+	if entity := s.escalating["api"]; entity == nil || entity.Tiers != len(testEscalationPolicy.Tiers) {
+		t.Fatalf("reached tiers should be clamped to the policy. got: %+v\n", entity)
+	}
+	s.escalateDue(s.escalating["api"], down.Time.Add(time.Hour))
+
+	s.escalate(newTestEscalatedEvent(prober.UpStatus))
+	notifications := queued(t, deliveries)
+	if len(notifications["chat"]) != 1 || len(notifications["mail"]) != 1 || len(notifications["pager"]) != 1 {
+		t.Errorf("recovery should be notified to every tier of the policy. got: %+v\n", notifications)
+	}
+}
+
+func TestEscalationPolicyValidate(t *testing.T) {
+	receivers := map[string]bool{"chat": true, "mail": true, "pager": true}
+	if err := testEscalationPolicy.validate(receivers); err != nil {
+		t.Fatalf("escalation policy should be valid. got: %v\n", err)
+	}
+	invalid := []EscalationPolicy{
+		{Tiers: []EscalationTier{{Receivers: []string{"chat"}}}},
+		{Name: "empty"},
+		{Name: "no-receiver", Tiers: []EscalationTier{{}}},
+		{Name: "unknown", Tiers: []EscalationTier{{Receivers: []string{"sms"}}}},
+		{Name: "negative", Tiers: []EscalationTier{{After: -time.Minute, Receivers: []string{"chat"}}}},
+		{Name: "unordered", Tiers: []EscalationTier{
+			{After: time.Hour, Receivers: []string{"chat"}},
+			{After: time.Minute, Receivers: []string{"mail"}},
+		}},
+	}
+	for _, ep := range invalid {
+		if err := ep.validate(receivers); err == nil {
+			t.Errorf("escalation policy should be invalid: %+v\n", ep)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/command"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default limits of the commands.
+const (
+	defaultExecTimeout     = 30 * time.Second
+	defaultExecConcurrency = 1
+	// Output of a command that is logged, the rest is discarded.
+	maxExecOutputSize = 4 << 10
+)
+
+// NewExecAlerter runs the configured command for every status change.
+func NewExecAlerter(ec *ExecConfiguration, mt *messageTemplate) (*ExecAlerter, error) {
+	return &ExecAlerter{
+		config:   ec,
+		template: mt,
+		slots:    make(chan struct{}, ec.Concurrency),
+	}, nil
+}
+
+// Alert runs the command once per status change of the notification, at most Concurrency at a time,
+// and waits for all of them. Reminders run the command again. When some of the commands fail,
+// only their status changes are retried by the queue.
+func (ea *ExecAlerter) Alert(notification Notification) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(notification.Events))
+	for i, event := range notification.Events {
+		wg.Add(1)
+		ea.slots <- struct{}{}
+		go func(i int, event prober.Event) {
+			defer wg.Done()
+			defer func() { <-ea.slots }()
+			errs[i] = ea.run(notification, event)
+		}(i, event)
+	}
+	wg.Wait()
+
+	remaining := notification
+	remaining.Events = nil
+	var last error
+	for i, err := range errs {
+		if err != nil {
+			remaining.Events = append(remaining.Events, notification.Events[i])
+			last = err
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	if failed := len(remaining.Events); failed > 1 {
+		last = fmt.Errorf("%d commands failed, last one: %w", failed, last)
+	}
+	if len(remaining.Events) < len(notification.Events) {
+		return &partialError{remaining: remaining, err: last}
+	}
+	return last
+}
+
+// run executes the command for a single status change, passing the alert context as environment
+// variables and the template data as JSON on stdin. Its output is logged once it exits.
+func (ea *ExecAlerter) run(notification Notification, event prober.Event) error {
+	notification.Events = []prober.Event{event}
+	data := newTemplateData(notification, ea.template.externalURL)
+	stdin, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	message, err := ea.template.render(notification)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ea.config.Timeout)
+	defer cancel()
+	cmd := exec.Command(ea.config.Command, ea.config.Args...)
+	cmd.Env = append(os.Environ(), execEnv(data, message)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	stdout, stderr := command.NewOutput(maxExecOutputSize), command.NewOutput(maxExecOutputSize)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = command.Run(ctx, cmd)
+	name := event.Probe.Name
+	ea.log(name, "stdout", stdout)
+	ea.log(name, "stderr", stderr)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("command [%s] for probe [%s] timed out after %s", ea.config.Command, name, ea.config.Timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("command [%s] for probe [%s] failed: %w", ea.config.Command, name, err)
+	}
+	return nil
+}
+
+// log logs the output of the command for the probe, if any.
+func (ea *ExecAlerter) log(name, stream string, output *command.Output) {
+	out := strings.TrimSpace(output.String())
+	if out == "" {
+		return
+	}
+	if output.Truncated() {
+		out += " [truncated]"
+	}
+	log.Printf("Command [%s] for probe [%s] %s: %s\n", ea.config.Command, name, stream, out)
+}
+
+// execEnv returns the environment variables describing the status change of the single alert of the data.
+func execEnv(data TemplateData, message string) []string {
+	alert := data.Alerts[0]
+	env := []string{
+		"MADPROBE_RECEIVER=" + data.Receiver,
+		"MADPROBE_REPEAT=" + strconv.FormatBool(data.Repeat),
+		"MADPROBE_MESSAGE=" + message,
+		"MADPROBE_PROBE_NAME=" + alert.Name,
+		"MADPROBE_PROBE_URL=" + alert.URL,
+		"MADPROBE_PROBE_SEVERITY=" + alert.Severity,
+		"MADPROBE_STATUS=" + alert.Status,
+		"MADPROBE_PREVIOUS_STATUS=" + alert.PreviousStatus,
+		"MADPROBE_RECOVERED=" + strconv.FormatBool(alert.Recovered),
+		"MADPROBE_TIME=" + alert.Time.Format(time.RFC3339),
+		"MADPROBE_OUTAGE_SECONDS=" + strconv.Itoa(int(alert.OutageDuration.Seconds())),
+		"MADPROBE_LAST_ERROR=" + alert.LastError,
+		"MADPROBE_STATUS_CODE=" + strconv.Itoa(alert.StatusCode),
+		"MADPROBE_PROBE_LINK=" + alert.ProbeURL,
+	}
+	for name, value := range alert.Labels {
+		env = append(env, "MADPROBE_LABEL_"+envName(name)+"="+value)
+	}
+	return env
+}
+
+// envName turns a label name into an environment variable name, e.g. "app.kubernetes.io/name"
+// becomes "APP_KUBERNETES_IO_NAME".
+func envName(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, label)
+}
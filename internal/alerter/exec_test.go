@@ -0,0 +1,144 @@
+package alerter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestExecAlerter(t *testing.T, ec *ExecConfiguration) *ExecAlerter {
+	if err := ec.validate(); err != nil {
+		t.Fatalf("exec configuration should be valid. got: %v\n", err)
+	}
+	mt, err := newMessageTemplate("exec", incidentTemplate, "")
+	if err != nil {
+		t.Fatalf("default exec template should be valid. got: %v\n", err)
+	}
+	ea, _ := NewExecAlerter(ec, mt)
+	return ea
+}
+
+func TestExecAlertPassesContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madprobe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := `echo "$MADPROBE_PROBE_NAME $MADPROBE_STATUS $MADPROBE_LABEL_TEAM $MADPROBE_MESSAGE" > "$1/env"; cat > "$1/stdin"; echo done`
+	ea := newTestExecAlerter(t, &ExecConfiguration{Command: "sh", Args: []string{"-c", script, "sh", dir}})
+	notification := newTestWebhookNotification()
+	notification.Events[0].Probe.Labels = map[string]string{"team": "payments"}
+
+	if err := ea.Alert(notification); err != nil {
+		t.Fatalf("command should succeed. got: %v\n", err)
+	}
+	env, _ := ioutil.ReadFile(filepath.Join(dir, "env"))
+	if strings.TrimSpace(string(env)) != "api DOWN payments Probe [api] is DOWN: timeout" {
+		t.Errorf("alert context should be passed as environment variables. got: %s\n", env)
+	}
+	stdin, _ := ioutil.ReadFile(filepath.Join(dir, "stdin"))
+	var data TemplateData
+	if err := json.Unmarshal(stdin, &data); err != nil || len(data.Alerts) != 1 || data.Alerts[0].Name != "api" {
+		t.Errorf("alert context should be passed as JSON on stdin. got: %s\n", stdin)
+	}
+}
+
+func TestExecAlertFailures(t *testing.T) {
+	ea := newTestExecAlerter(t, &ExecConfiguration{Command: "sh", Args: []string{"-c", "echo oops >&2; exit 3"}})
+	if err := ea.Alert(newTestWebhookNotification()); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("command failure should be reported. got: %v\n", err)
+	}
+
+	ea = newTestExecAlerter(t, &ExecConfiguration{Command: "sleep", Args: []string{"5"}, Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	if err := ea.Alert(newTestWebhookNotification()); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("command timeout should be reported. got: %v\n", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("command should be killed after the timeout. took: %s\n", time.Since(start))
+	}
+}
+
+func TestExecAlertConcurrency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madprobe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// mkdir fails if another command holds the lock.
+	script := `mkdir "$1/lock" && sleep 0.1 && rmdir "$1/lock"`
+	ea := newTestExecAlerter(t, &ExecConfiguration{Command: "sh", Args: []string{"-c", script, "sh", dir}})
+	notification := newTestWebhookNotification()
+	other := notification.Events[0]
+	other.Probe.Name = "db"
+	notification.Events = append(notification.Events, other, other)
+
+	if err := ea.Alert(notification); err != nil {
+		t.Errorf("commands should run one at a time. got: %v\n", err)
+	}
+}
+
+func TestExecAlertRetriesFailedCommandsOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madprobe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The command fails the first time it runs for the probe db.
+	script := `echo "$MADPROBE_PROBE_NAME" >> "$1/runs"; [ "$MADPROBE_PROBE_NAME" != db ] || [ -e "$1/failed" ] || { touch "$1/failed"; exit 1; }`
+	ea := newTestExecAlerter(t, &ExecConfiguration{Command: "sh", Args: []string{"-c", script, "sh", dir}})
+	deliveries := newFakeDeliveries()
+	q := newTestQueue(ea, deliveries)
+	defer q.close()
+	notification := newTestWebhookNotification()
+	other := notification.Events[0]
+	other.Probe.Name = "db"
+	notification.Events = append(notification.Events, other)
+
+	if err := q.push(notification); err != nil {
+		t.Fatalf("notification should be queued. got: %v\n", err)
+	}
+	eventually(t, func() bool { return q.size() == 0 }, "notification should be delivered once the failed command succeeds")
+	runs, _ := ioutil.ReadFile(filepath.Join(dir, "runs"))
+	if string(runs) != "api\ndb\ndb\n" {
+		t.Errorf("only the failed command should be run again. got: %q\n", runs)
+	}
+}
+
+func TestExecAlertTruncatesOutput(t *testing.T) {
+	ea := newTestExecAlerter(t, &ExecConfiguration{Command: "sh", Args: []string{"-c", "head -c 1000000 /dev/zero | tr '\\0' a"}})
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	if err := ea.Alert(newTestWebhookNotification()); err != nil {
+		t.Fatalf("command flooding its output should succeed. got: %v\n", err)
+	}
+	if logs.Len() > 2*maxExecOutputSize || !strings.Contains(logs.String(), "[truncated]") {
+		t.Errorf("logged output should be truncated. got: %d bytes\n", logs.Len())
+	}
+}
+
+func TestExecConfigurationValidate(t *testing.T) {
+	ec := &ExecConfiguration{Command: "sh"}
+	if err := ec.validate(); err != nil || ec.Timeout != defaultExecTimeout || ec.Concurrency != 1 {
+		t.Errorf("exec configuration should get default limits. got: %v %+v\n", err, ec)
+	}
+	for _, invalid := range []*ExecConfiguration{{}, {Command: "madprobe-unknown-command"}, {Command: "sh", Concurrency: -1}} {
+		if err := invalid.validate(); err == nil {
+			t.Errorf("exec configuration should be invalid: %+v\n", invalid)
+		}
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if name := envName("app.kubernetes.io/name"); name != "APP_KUBERNETES_IO_NAME" {
+		t.Errorf("label name should be turned into a variable name. got: %s\n", name)
+	}
+}
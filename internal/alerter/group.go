@@ -0,0 +1,202 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// groupKey identifies an aggregation group: a route and the values of its group-by labels.
+type groupKey struct {
+	route  *Route
+	labels string
+}
+
+// group aggregates the status changes of the probes sharing the same group-by labels on a route.
+// Changes are batched during the route group wait, probes notified twice with the same status
+// are deduplicated and reminders about the probes still DOWN are sent every repeat interval.
+type group struct {
+	mu       sync.Mutex
+	route    *Route
+	labels   map[string]string
+	silences silencer.SilenceService
+	// Returns true if the outage of the probe has been acknowledged.
+	acknowledged func(probe string) bool
+	notify       func(Notification)
+	// Called when the group is left without pending changes, DOWN probes and timers.
+	release func(*group)
+
+	// Status changes waiting for the end of the group wait.
+	pending map[string]prober.Event
+	// Last status notified for every probe of the group.
+	notified map[string]string
+	// Probes whose last status change was suppressed by a silence. Their recovery is suppressed too
+	// unless an earlier status was notified, so that no recovery is sent for an outage nobody heard of.
+	suppressed map[string]bool
+	// Last status change of the probes of the group currently DOWN.
+	firing map[string]prober.Event
+
+	flushTimer  *time.Timer
+	repeatTimer *time.Timer
+}
+
+func newGroup(route *Route, labels map[string]string, silences silencer.SilenceService, acknowledged func(string) bool, notify func(Notification), release func(*group)) *group {
+	return &group{
+		route:        route,
+		labels:       labels,
+		silences:     silences,
+		acknowledged: acknowledged,
+		notify:       notify,
+		release:      release,
+		pending:      make(map[string]prober.Event),
+		notified:     make(map[string]string),
+		suppressed:   make(map[string]bool),
+		firing:       make(map[string]prober.Event),
+	}
+}
+
+// add registers a status change of a probe and schedules the flush of the group.
+func (g *group) add(event prober.Event) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if event.Probe.Status == prober.DownStatus {
+		g.firing[event.Probe.Name] = event
+	} else {
+		delete(g.firing, event.Probe.Name)
+	}
+	g.pending[event.Probe.Name] = event
+	if g.flushTimer == nil {
+		g.flushTimer = time.AfterFunc(g.route.GroupWait, g.flush)
+	}
+}
+
+// flush notifies the pending status changes that were not already notified.
+func (g *group) flush() {
+	g.mu.Lock()
+	var events []prober.Event
+	for name, event := range g.pending {
+		if g.notified[name] == event.Probe.Status {
+			continue
+		}
+		if g.silenced(event.Probe) {
+			g.suppressed[name] = true
+			continue
+		}
+		suppressed := g.suppressed[name]
+		delete(g.suppressed, name)
+		if suppressed && g.notified[name] == "" && event.Probe.Status == prober.UpStatus {
+			log.Printf("Recovery of probe [%s] suppressed, its outage was silenced.\n", name)
+			continue
+		}
+		g.notified[name] = event.Probe.Status
+		events = append(events, event)
+	}
+	g.pending = make(map[string]prober.Event)
+	g.flushTimer = nil
+	g.scheduleRepeat()
+	idle := g.isIdle()
+	g.mu.Unlock()
+
+	if len(events) > 0 {
+		g.notify(g.notification(events, false))
+	}
+	if idle {
+		g.release(g)
+	}
+}
+
+// repeat reminds about the probes of the group that are still DOWN and not acknowledged.
+func (g *group) repeat() {
+	g.mu.Lock()
+	var events []prober.Event
+	for _, event := range g.firing {
+		if g.silenced(event.Probe) || g.acknowledged(event.Probe.Name) {
+			continue
+		}
+		g.notified[event.Probe.Name] = event.Probe.Status
+		delete(g.suppressed, event.Probe.Name)
+		events = append(events, event)
+	}
+	g.repeatTimer = nil
+	g.scheduleRepeat()
+	idle := g.isIdle()
+	g.mu.Unlock()
+
+	if len(events) > 0 {
+		g.notify(g.notification(events, true))
+	}
+	if idle {
+		g.release(g)
+	}
+}
+
+// idle returns true if the group has nothing left to notify and can be dropped.
+func (g *group) idle() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.isIdle()
+}
+
+// isIdle must be called with the lock held.
+func (g *group) isIdle() bool {
+	return len(g.pending) == 0 && len(g.firing) == 0 && g.flushTimer == nil && g.repeatTimer == nil
+}
+
+// scheduleRepeat starts the reminder timer if some probes are DOWN
+// and stops it when every probe recovered. Must be called with the lock held.
+func (g *group) scheduleRepeat() {
+	if g.route.RepeatInterval <= 0 {
+		return
+	}
+	if len(g.firing) == 0 {
+		if g.repeatTimer != nil {
+			g.repeatTimer.Stop()
+			g.repeatTimer = nil
+		}
+		return
+	}
+	if g.repeatTimer == nil {
+		g.repeatTimer = time.AfterFunc(g.route.RepeatInterval, g.repeat)
+	}
+}
+
+func (g *group) silenced(probe prober.Probe) bool {
+	active := g.silences.Active(probe)
+	if len(active) > 0 {
+		log.Printf("Alert for probe [%s] suppressed by silence [%s].\n", probe.Name, active[0].Name)
+		return true
+	}
+	return false
+}
+
+func (g *group) notification(events []prober.Event, repeat bool) Notification {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Probe.Name < events[j].Probe.Name
+	})
+	return Notification{
+		Receiver:    g.route.Receiver,
+		Recipients:  g.route.Recipients,
+		GroupLabels: g.labels,
+		Events:      events,
+		Repeat:      repeat,
+	}
+}
+
+// groupLabels returns the values of the route group-by labels for the given probe
+// and the key identifying its group. Without group-by labels, each probe has its own group.
+func groupLabels(route *Route, probe prober.Probe) (map[string]string, string) {
+	labels := make(map[string]string)
+	if len(route.GroupBy) == 0 {
+		return labels, "name=" + probe.Name
+	}
+	var key []string
+	for _, name := range route.GroupBy {
+		labels[name] = probe.Labels[name]
+		key = append(key, name+"="+probe.Labels[name])
+	}
+	return labels, strings.Join(key, ",")
+}
@@ -0,0 +1,211 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fake of the interface SilenceService muting the probes listed in silenced.
+// Inserted silences are kept in inserted.
+type fakeSilences struct {
+	silenced map[string]bool
+	inserted []silencer.Silence
+}
+
+func (f *fakeSilences) Insert(silence silencer.Silence) error {
+	f.inserted = append(f.inserted, silence)
+	return nil
+}
+func (f *fakeSilences) Get(_ string) (*silencer.Silence, error) { return nil, nil }
+func (f *fakeSilences) GetAll() ([]*silencer.Silence, error)    { return nil, nil }
+func (f *fakeSilences) Delete(_ string) error                   { return nil }
+func (f *fakeSilences) Active(probe prober.Probe) []*silencer.Silence {
+	if f.silenced[probe.Name] {
+		return []*silencer.Silence{{Name: "fake"}}
+	}
+	return nil
+}
+
+func newTestGroup(route *Route, silenced map[string]bool) (*group, chan Notification) {
+	return newTestAckGroup(route, silenced, func(string) bool { return false })
+}
+
+func newTestAckGroup(route *Route, silenced map[string]bool, acknowledged func(string) bool) (*group, chan Notification) {
+	notifications := make(chan Notification, 16)
+	g := newGroup(route, nil, &fakeSilences{silenced: silenced}, acknowledged, func(n Notification) {
+		notifications <- n
+	}, func(*group) {})
+	return g, notifications
+}
+
+func newTestEvent(name, status string) prober.Event {
+	probe := prober.NewProbe(name, "http://localhost/", 5)
+	probe.Status = status
+	return prober.NewEvent(*probe, "", time.Now())
+}
+
+func receive(t *testing.T, notifications <-chan Notification) Notification {
+	select {
+	case n := <-notifications:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("a notification should have been sent")
+	}
+	return Notification{}
+}
+
+func expectNothing(t *testing.T, notifications <-chan Notification, wait time.Duration) {
+	select {
+	case n := <-notifications:
+		t.Errorf("no notification should have been sent. got: %+v\n", n)
+	case <-time.After(wait):
+	}
+}
+
+func TestGroupBatchesChangesDuringGroupWait(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops", GroupWait: 50 * time.Millisecond}, nil)
+	g.add(newTestEvent("b", prober.DownStatus))
+	g.add(newTestEvent("a", prober.DownStatus))
+
+	n := receive(t, notifications)
+	if len(n.Events) != 2 || n.Events[0].Probe.Name != "a" || n.Events[1].Probe.Name != "b" {
+		t.Errorf("both probes should be notified together sorted by name. got: %+v\n", n.Events)
+	}
+	if n.Receiver != "ops" || n.Repeat {
+		t.Errorf("notification should be sent to [ops] and not be a reminder. got: %+v\n", n)
+	}
+}
+
+func TestGroupDeduplicatesNotifiedStatus(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops"}, nil)
+	g.add(newTestEvent("a", prober.DownStatus))
+	receive(t, notifications)
+
+	g.add(newTestEvent("a", prober.DownStatus))
+	expectNothing(t, notifications, 50*time.Millisecond)
+
+	g.add(newTestEvent("a", prober.UpStatus))
+	if n := receive(t, notifications); n.Events[0].Probe.Status != prober.UpStatus {
+		t.Errorf("recovery should be notified. got: %+v\n", n.Events)
+	}
+}
+
+func TestGroupRemindsProbesStillDown(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops", RepeatInterval: 50 * time.Millisecond}, nil)
+	g.add(newTestEvent("a", prober.DownStatus))
+	receive(t, notifications)
+
+	n := receive(t, notifications)
+	if !n.Repeat || len(n.Events) != 1 || n.Events[0].Probe.Name != "a" {
+		t.Errorf("a reminder about probe [a] should be sent. got: %+v\n", n)
+	}
+
+	g.add(newTestEvent("a", prober.UpStatus))
+	receive(t, notifications)
+	expectNothing(t, notifications, 100*time.Millisecond)
+}
+
+func TestGroupStopsRemindingAcknowledgedProbes(t *testing.T) {
+	var acknowledged int32
+	g, notifications := newTestAckGroup(&Route{Receiver: "ops", RepeatInterval: 50 * time.Millisecond}, nil, func(probe string) bool {
+		return probe == "a" && atomic.LoadInt32(&acknowledged) == 1
+	})
+	g.add(newTestEvent("a", prober.DownStatus))
+	receive(t, notifications)
+	receive(t, notifications)
+
+	atomic.StoreInt32(&acknowledged, 1)
+	expectNothing(t, notifications, 150*time.Millisecond)
+
+	g.add(newTestEvent("a", prober.UpStatus))
+	if n := receive(t, notifications); n.Repeat || n.Events[0].Probe.Status != prober.UpStatus {
+		t.Errorf("recovery of an acknowledged probe should be notified. got: %+v\n", n)
+	}
+}
+
+func TestGroupSkipsSilencedProbes(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops"}, map[string]bool{"a": true})
+	g.add(newTestEvent("a", prober.DownStatus))
+	g.add(newTestEvent("b", prober.DownStatus))
+
+	n := receive(t, notifications)
+	if len(n.Events) != 1 || n.Events[0].Probe.Name != "b" {
+		t.Errorf("silenced probe [a] should not be notified. got: %+v\n", n.Events)
+	}
+}
+
+func TestGroupSuppressesRecoveryOfSilencedOutage(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops"}, map[string]bool{"a": true})
+	g.add(newTestEvent("a", prober.DownStatus))
+	expectNothing(t, notifications, 50*time.Millisecond)
+
+	// The silence expires before the probe recovers.
+	g.mu.Lock()
+	g.silences = &fakeSilences{}
+	g.mu.Unlock()
+	g.add(newTestEvent("a", prober.UpStatus))
+	expectNothing(t, notifications, 50*time.Millisecond)
+
+	g.add(newTestEvent("a", prober.DownStatus))
+	if n := receive(t, notifications); n.Events[0].Probe.Status != prober.DownStatus {
+		t.Errorf("next outage should be notified. got: %+v\n", n.Events)
+	}
+	g.add(newTestEvent("a", prober.UpStatus))
+	if n := receive(t, notifications); n.Events[0].Probe.Status != prober.UpStatus {
+		t.Errorf("recovery of a notified outage should be notified. got: %+v\n", n.Events)
+	}
+}
+
+func TestGroupNotifiesRecoveryOfRemindedOutage(t *testing.T) {
+	g, notifications := newTestGroup(&Route{Receiver: "ops", RepeatInterval: 50 * time.Millisecond}, map[string]bool{"a": true})
+	g.add(newTestEvent("a", prober.DownStatus))
+	expectNothing(t, notifications, 20*time.Millisecond)
+
+	// The silence expires while the probe is still DOWN, the reminder notifies the outage.
+	g.mu.Lock()
+	g.silences = &fakeSilences{}
+	g.mu.Unlock()
+	if n := receive(t, notifications); !n.Repeat {
+		t.Errorf("a reminder about probe [a] should be sent once the silence expired. got: %+v\n", n)
+	}
+	g.add(newTestEvent("a", prober.UpStatus))
+	if n := receive(t, notifications); n.Events[0].Probe.Status != prober.UpStatus {
+		t.Errorf("recovery of a reminded outage should be notified. got: %+v\n", n.Events)
+	}
+}
+
+func TestServiceReleasesIdleGroups(t *testing.T) {
+	s := newService(make(chan prober.Event), &fakeSilences{}, newFakeDeliveries(), &Configuration{Queue: testQueueConfiguration})
+	s.addReceiver("ops", newFakeAlerter(0), nil)
+	route := &Route{Receiver: "ops", RepeatInterval: time.Hour}
+	groups := func() int {
+		s.gmu.Lock()
+		defer s.gmu.Unlock()
+		return len(s.groups)
+	}
+
+	s.addToGroup(route, newTestEvent("a", prober.DownStatus))
+	time.Sleep(50 * time.Millisecond)
+	if groups() != 1 {
+		t.Fatalf("group of a DOWN probe should be kept for its reminders\n")
+	}
+	s.addToGroup(route, newTestEvent("a", prober.UpStatus))
+	eventually(t, func() bool { return groups() == 0 }, "group should be dropped once every probe recovered")
+}
+
+func TestGroupLabels(t *testing.T) {
+	probe := newTestEvent("a", prober.DownStatus).Probe
+	probe.Labels = map[string]string{"team": "payments", "env": "prod"}
+
+	_, key := groupLabels(&Route{}, probe)
+	if key != "name=a" {
+		t.Errorf("probes should be grouped by name without group-by labels. got: %s\n", key)
+	}
+	labels, key := groupLabels(&Route{GroupBy: []string{"team"}}, probe)
+	if key != "team=payments" || labels["team"] != "payments" || len(labels) != 1 {
+		t.Errorf("probes should be grouped by team. got: %s %v\n", key, labels)
+	}
+}
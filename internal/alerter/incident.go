@@ -0,0 +1,104 @@
+package alerter
+
+import (
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Default template of the incident summaries, rendered for every probe on its own.
+const incidentTemplate = `{{ range .Alerts }}Probe [{{ .Name }}] is {{ .Status }}{{ if .LastError }}: {{ .LastError }}{{ end }}{{ end }}`
+
+// Incident actions derived from a status change.
+const (
+	triggerAction = "trigger"
+	resolveAction = "resolve"
+)
+
+// incidentAction returns the action to take for a status change: trigger when a probe goes DOWN,
+// resolve when it recovers and nothing otherwise. Reminders are skipped as the incident is already open.
+func incidentAction(notification Notification, event prober.Event) string {
+	switch {
+	case notification.Repeat:
+		return ""
+	case event.Probe.Status == prober.DownStatus:
+		return triggerAction
+	case event.PreviousStatus == prober.DownStatus:
+		return resolveAction
+	}
+	return ""
+}
+
+// dedupKey identifies the incident of a probe, so that the resolve event closes the one triggered.
+func dedupKey(probe prober.Probe) string {
+	return "madprobe-" + probe.Name
+}
+
+// renderEvent renders the receiver template for a single status change of the notification.
+func renderEvent(mt *messageTemplate, notification Notification, event prober.Event) (string, error) {
+	notification.Events = []prober.Event{event}
+	return mt.render(notification)
+}
+
+// incidentDetails are the custom fields attached to the incidents.
+func incidentDetails(event prober.Event) map[string]string {
+	details := map[string]string{
+		"url":      event.Probe.URL,
+		"status":   event.Probe.Status,
+		"severity": event.Probe.EffectiveSeverity(),
+	}
+	if event.Probe.LastError != "" {
+		details["last_error"] = event.Probe.LastError
+	}
+	if event.Probe.StatusCode != 0 {
+		details["status_code"] = strconv.Itoa(event.Probe.StatusCode)
+	}
+	for name, value := range event.Probe.Labels {
+		details["label_"+name] = value
+	}
+	return details
+}
+
+// sendIncidents applies the action of every status change of the notification,
+// keeps going on failure and reports how many of them failed.
+func sendIncidents(notification Notification, send func(action string, event prober.Event) error) error {
+	var failed int
+	var last error
+	for _, event := range notification.Events {
+		action := incidentAction(notification, event)
+		if action == "" {
+			continue
+		}
+		if err := send(action, event); err != nil {
+			failed++
+			last = fmt.Errorf("could not %s incident of probe [%s]: %w", action, event.Probe.Name, err)
+		}
+	}
+	if failed > 1 {
+		return fmt.Errorf("%d incidents failed, last one: %w", failed, last)
+	}
+	return last
+}
+
+// truncate shortens the text to the given number of runes, as incident fields are limited.
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// truncateBytes shortens the text to the given number of bytes without splitting a rune,
+// for the fields whose limit is a payload size.
+func truncateBytes(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
@@ -0,0 +1,123 @@
+package alerter
+
+import (
+	"encoding/json"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// incidentRequest is a call received by the incident management stand-in.
+type incidentRequest struct {
+	uri           string
+	authorization string
+	body          map[string]interface{}
+}
+
+func newIncidentServer() (*httptest.Server, chan incidentRequest) {
+	requests := make(chan incidentRequest, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := ioutil.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(raw, &body)
+		requests <- incidentRequest{uri: r.RequestURI, authorization: r.Header.Get("Authorization"), body: body}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return server, requests
+}
+
+// newIncidentNotifications returns the notifications of a probe going DOWN, reminded about, then recovering.
+func newIncidentNotifications() (down, repeat, up Notification) {
+	now := time.Now()
+	probe := prober.NewProbe("api/v1", "http://localhost/health", 5)
+	probe.Labels = map[string]string{"team": "payments"}
+	probe.Severity = prober.WarningSeverity
+	probe.Status = prober.DownStatus
+	probe.LastError = "timeout"
+	down = Notification{Events: []prober.Event{prober.NewEvent(*probe, prober.UpStatus, now)}}
+	repeat = Notification{Events: down.Events, Repeat: true}
+	probe.Status = prober.UpStatus
+	up = Notification{Events: []prober.Event{prober.NewEvent(*probe, prober.DownStatus, now.Add(time.Minute))}}
+	return down, repeat, up
+}
+
+func TestPagerDutyTriggersAndResolves(t *testing.T) {
+	server, requests := newIncidentServer()
+	defer server.Close()
+	mt, _ := newMessageTemplate("pagerduty", incidentTemplate, "https://madprobe.example.com")
+	pa, _ := NewPagerDutyAlerter(&PagerDutyConfiguration{RoutingKey: "key", URL: server.URL + "/v2/enqueue"}, mt)
+	down, repeat, up := newIncidentNotifications()
+
+	if err := pa.Alert(down); err != nil {
+		t.Fatalf("trigger event should be sent. got: %v\n", err)
+	}
+	trigger := (<-requests).body
+	if trigger["routing_key"] != "key" || trigger["event_action"] != "trigger" || trigger["dedup_key"] != "madprobe-api/v1" {
+		t.Errorf("trigger event should be keyed after the probe. got: %v\n", trigger)
+	}
+	if lookup(trigger, "payload", "summary") != "Probe [api/v1] is DOWN: timeout" || lookup(trigger, "payload", "severity") != "warning" {
+		t.Errorf("trigger payload should summarize the outage. got: %v\n", trigger["payload"])
+	}
+	if lookup(trigger, "links", 0, "href") != "https://madprobe.example.com/api/v1/probe/api%2Fv1" {
+		t.Errorf("trigger event should link the probe. got: %v\n", trigger["links"])
+	}
+
+	if err := pa.Alert(repeat); err != nil {
+		t.Fatalf("reminder should be ignored. got: %v\n", err)
+	}
+	if err := pa.Alert(up); err != nil {
+		t.Fatalf("resolve event should be sent. got: %v\n", err)
+	}
+	resolve := (<-requests).body
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != "madprobe-api/v1" || resolve["payload"] != nil {
+		t.Errorf("resolve event should close the incident of the probe. got: %v\n", resolve)
+	}
+}
+
+func TestOpsgenieCreatesAndCloses(t *testing.T) {
+	server, requests := newIncidentServer()
+	defer server.Close()
+	mt, _ := newMessageTemplate("opsgenie", incidentTemplate, "")
+	oa, _ := NewOpsgenieAlerter(&OpsgenieConfiguration{APIKey: "key", URL: server.URL}, mt)
+	down, repeat, up := newIncidentNotifications()
+
+	if err := oa.Alert(down); err != nil {
+		t.Fatalf("alert should be created. got: %v\n", err)
+	}
+	create := <-requests
+	if create.uri != "/v2/alerts" || create.authorization != "GenieKey key" {
+		t.Errorf("alert should be created with the API key. got: %s %s\n", create.uri, create.authorization)
+	}
+	if create.body["alias"] != "madprobe-api/v1" || create.body["priority"] != "P3" || create.body["message"] != "Probe [api/v1] is DOWN" {
+		t.Errorf("alert should be keyed after the probe. got: %v\n", create.body)
+	}
+	if lookup(create.body, "tags", 0) != "team:payments" {
+		t.Errorf("alert should be tagged with the probe labels. got: %v\n", create.body["tags"])
+	}
+
+	if err := oa.Alert(repeat); err != nil {
+		t.Fatalf("reminder should be ignored. got: %v\n", err)
+	}
+	if err := oa.Alert(up); err != nil {
+		t.Fatalf("alert should be closed. got: %v\n", err)
+	}
+	if closing := <-requests; closing.uri != "/v2/alerts/madprobe-api%2Fv1/close?identifierType=alias" {
+		t.Errorf("alert of the probe should be closed by alias. got: %s\n", closing.uri)
+	}
+}
+
+func TestIncidentFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid routing key", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	mt, _ := newMessageTemplate("pagerduty", incidentTemplate, "")
+	pa, _ := NewPagerDutyAlerter(&PagerDutyConfiguration{RoutingKey: "key", URL: server.URL}, mt)
+	down, _, _ := newIncidentNotifications()
+	if err := pa.Alert(down); err == nil {
+		t.Error("rejected event should be reported")
+	}
+}
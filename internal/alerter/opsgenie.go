@@ -0,0 +1,90 @@
+package alerter
+
+import (
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Default endpoint of the Opsgenie API, https://api.eu.opsgenie.com for the EU instance.
+const defaultOpsgenieURL = "https://api.opsgenie.com"
+
+// Priorities of the Opsgenie alerts by probe severity.
+var opsgeniePriorities = map[string]string{
+	prober.CriticalSeverity: "P1",
+	prober.WarningSeverity:  "P3",
+	prober.InfoSeverity:     "P5",
+}
+
+// NewOpsgenieAlerter sends the alerts to the team of the configured API integration key.
+func NewOpsgenieAlerter(oc *OpsgenieConfiguration, mt *messageTemplate) (*OpsgenieAlerter, error) {
+	return &OpsgenieAlerter{
+		config:   oc,
+		client:   newWebhookClient(),
+		template: mt,
+	}, nil
+}
+
+// Alert creates an alert for every probe going DOWN and closes it when the probe recovers.
+func (oa *OpsgenieAlerter) Alert(notification Notification) error {
+	header := http.Header{"Authorization": {"GenieKey " + oa.config.APIKey}}
+	base := strings.TrimSuffix(oa.config.URL, "/") + "/v2/alerts"
+	return sendIncidents(notification, func(action string, event prober.Event) error {
+		alias := dedupKey(event.Probe)
+		if action == resolveAction {
+			closeURL := fmt.Sprintf("%s/%s/close?identifierType=alias", base, url.PathEscape(alias))
+			return postJSON(oa.client, closeURL, header, opsgenieClose{Source: "madprobe", Note: "Probe recovered"})
+		}
+		alert, err := oa.alert(notification, event, alias)
+		if err != nil {
+			return err
+		}
+		return postJSON(oa.client, base, header, alert)
+	})
+}
+
+// opsgenieAlert is the body of an alert creation, alerts with the same alias are deduplicated.
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity"`
+	Priority    string            `json:"priority"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+func (oa *OpsgenieAlerter) alert(notification Notification, event prober.Event, alias string) (*opsgenieAlert, error) {
+	description, err := renderEvent(oa.template, notification, event)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for name, value := range event.Probe.Labels {
+		tags = append(tags, name+":"+value)
+	}
+	sort.Strings(tags)
+	details := incidentDetails(event)
+	if link := probeURL(oa.template.externalURL, event.Probe.Name); link != "" {
+		details["probe_url"] = link
+	}
+	return &opsgenieAlert{
+		Message:     truncate(notificationTitle(Notification{Events: []prober.Event{event}}), 130),
+		Alias:       alias,
+		Description: truncate(description, 15000),
+		Source:      "madprobe",
+		Entity:      event.Probe.Name,
+		Priority:    opsgeniePriorities[event.Probe.EffectiveSeverity()],
+		Tags:        tags,
+		Details:     details,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"time"
+)
+
+// Default endpoint of the PagerDuty Events API v2.
+const defaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// NewPagerDutyAlerter sends the incidents to the service of the configured integration key.
+func NewPagerDutyAlerter(pc *PagerDutyConfiguration, mt *messageTemplate) (*PagerDutyAlerter, error) {
+	return &PagerDutyAlerter{
+		config:   pc,
+		client:   newWebhookClient(),
+		template: mt,
+	}, nil
+}
+
+// Alert triggers an incident for every probe going DOWN and resolves it when the probe recovers.
+func (pa *PagerDutyAlerter) Alert(notification Notification) error {
+	return sendIncidents(notification, func(action string, event prober.Event) error {
+		pe, err := pa.event(notification, action, event)
+		if err != nil {
+			return err
+		}
+		return postJSON(pa.client, pa.config.URL, nil, pe)
+	})
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// pagerDutyEvent is the body of an Events API v2 call. Resolve events only need the dedup key.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+func (pa *PagerDutyAlerter) event(notification Notification, action string, event prober.Event) (*pagerDutyEvent, error) {
+	pe := &pagerDutyEvent{
+		RoutingKey:  pa.config.RoutingKey,
+		EventAction: action,
+		DedupKey:    dedupKey(event.Probe),
+	}
+	if action == resolveAction {
+		return pe, nil
+	}
+	summary, err := renderEvent(pa.template, notification, event)
+	if err != nil {
+		return nil, err
+	}
+	pe.Payload = &pagerDutyPayload{
+		Summary: truncate(summary, 1024),
+		Source:  event.Probe.URL,
+		// madprobe severities are a subset of the PagerDuty ones.
+		Severity:      event.Probe.EffectiveSeverity(),
+		Timestamp:     event.Time.Format(time.RFC3339),
+		Component:     event.Probe.Name,
+		CustomDetails: incidentDetails(event),
+	}
+	if link := probeURL(pa.template.externalURL, event.Probe.Name); link != "" {
+		pe.Links = []pagerDutyLink{{Href: link, Text: "Probe in madprobe"}}
+	}
+	return pe, nil
+}
@@ -0,0 +1,224 @@
+package alerter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"log"
+	"sync"
+	"time"
+)
+
+// Default retry policy of the delivery queues.
+const (
+	defaultMaxAttempts    = 10
+	defaultInitialBackoff = 10 * time.Second
+	defaultMaxBackoff     = 10 * time.Minute
+)
+
+// queue delivers the notifications of a receiver. Notifications are persisted before being sent
+// so that pending ones survive restarts. Failed deliveries are retried with an exponential backoff
+// and moved to the dead letters after MaxAttempts.
+type queue struct {
+	receiver  string
+	alerter   Alerter
+	persister persistence.DeliveryPersister
+	config    QueueConfiguration
+	// record reports the outcome of every attempt.
+	record func(receiver string, err error)
+
+	mu      sync.Mutex
+	pending []*persistence.DeliveryEntity
+	// Wakes the queue up when a delivery is pushed, buffered to a single signal.
+	wake chan struct{}
+	done chan struct{}
+}
+
+// partialError is returned by the alerters that delivered a notification only in part, e.g. to some of
+// its chats. The queue retries the remaining notification only, so that the delivered part isn't sent again.
+type partialError struct {
+	remaining Notification
+	err       error
+}
+
+func (pe *partialError) Error() string {
+	return pe.err.Error()
+}
+
+func (pe *partialError) Unwrap() error {
+	return pe.err
+}
+
+func newQueue(receiver string, a Alerter, persister persistence.DeliveryPersister, config QueueConfiguration, record func(string, error)) *queue {
+	return &queue{
+		receiver:  receiver,
+		alerter:   a,
+		persister: persister,
+		config:    config,
+		record:    record,
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// push persists the notification and schedules its delivery.
+func (q *queue) push(notification Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	entity := &persistence.DeliveryEntity{
+		Receiver:     q.receiver,
+		Notification: data,
+		CreatedAt:    time.Now(),
+	}
+	if err := q.persister.InsertDelivery(entity); err != nil {
+		return err
+	}
+	q.enqueue(entity)
+	return nil
+}
+
+// enqueue schedules the delivery of an already persisted entity.
+func (q *queue) enqueue(entity *persistence.DeliveryEntity) {
+	q.mu.Lock()
+	q.pending = append(q.pending, entity)
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// size returns the number of pending deliveries.
+func (q *queue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// run attempts the due deliveries until the queue is closed, sleeping until the next one is due.
+func (q *queue) run() {
+	for {
+		// A nil channel never fires, the queue sleeps until a push when nothing is pending.
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if wait, ok := q.deliverDue(); ok {
+			timer = time.NewTimer(wait)
+			fire = timer.C
+		}
+		select {
+		case <-q.wake:
+		case <-fire:
+		case <-q.done:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-q.done:
+			return
+		default:
+		}
+	}
+}
+
+func (q *queue) close() {
+	close(q.done)
+}
+
+// deliverDue attempts the deliveries in the order they were pushed, stopping at the first one
+// that is not due or fails, so that a later status change is never sent before an earlier one.
+// It returns how long to wait for the next attempt, false when nothing is pending.
+func (q *queue) deliverDue() (time.Duration, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return 0, false
+		}
+		head := q.pending[0]
+		q.mu.Unlock()
+
+		if wait := time.Until(head.NextAttempt); wait > 0 {
+			return wait, true
+		}
+		if !q.attempt(head) {
+			return time.Until(head.NextAttempt), true
+		}
+		q.remove(head)
+	}
+}
+
+// attempt sends the notification of the entity and returns true when it leaves the queue,
+// either because it was delivered or because it was moved to the dead letters.
+func (q *queue) attempt(entity *persistence.DeliveryEntity) bool {
+	var notification Notification
+	err := json.Unmarshal(entity.Notification, &notification)
+	if err != nil {
+		entity.LastError = fmt.Sprintf("notification can't be decoded: %v", err)
+		q.bury(entity)
+		return true
+	}
+	err = q.alerter.Alert(notification)
+	q.record(q.receiver, err)
+	if err == nil {
+		if err := q.persister.DeleteDelivery(entity.ID); err != nil {
+			log.Printf("[WARNING] receiver [%s] could not delete delivered notification [%d]. got: [%v]\n", q.receiver, entity.ID, err)
+		}
+		return true
+	}
+
+	var partial *partialError
+	if errors.As(err, &partial) {
+		data, err := json.Marshal(partial.remaining)
+		if err != nil {
+			log.Printf("[WARNING] receiver [%s] could not encode the undelivered part of notification [%d]. got: [%v]\n", q.receiver, entity.ID, err)
+		} else {
+			entity.Notification = data
+		}
+	}
+	entity.Attempts++
+	entity.LastError = err.Error()
+	if entity.Attempts >= q.config.MaxAttempts {
+		log.Printf("[WARNING] receiver [%s] gave up on notification [%d] after %d attempts. got: [%v]\n", q.receiver, entity.ID, entity.Attempts, err)
+		q.bury(entity)
+		return true
+	}
+	entity.NextAttempt = time.Now().Add(q.backoff(entity.Attempts))
+	log.Printf("[WARNING] receiver [%s] could not send notification [%d], retrying at %s. got: [%v]\n", q.receiver, entity.ID, entity.NextAttempt.Format(time.RFC3339), err)
+	if err := q.persister.InsertDelivery(entity); err != nil {
+		log.Printf("[WARNING] receiver [%s] could not save notification [%d]. got: [%v]\n", q.receiver, entity.ID, err)
+	}
+	return false
+}
+
+func (q *queue) bury(entity *persistence.DeliveryEntity) {
+	if err := q.persister.BuryDelivery(entity); err != nil {
+		log.Printf("[WARNING] receiver [%s] could not move notification [%d] to the dead letters. got: [%v]\n", q.receiver, entity.ID, err)
+	}
+}
+
+func (q *queue) remove(entity *persistence.DeliveryEntity) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, pending := range q.pending {
+		if pending == entity {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// backoff doubles the initial backoff on every failed attempt, up to the max backoff.
+func (q *queue) backoff(attempts int) time.Duration {
+	backoff := q.config.InitialBackoff
+	for i := 1; i < attempts && backoff < q.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > q.config.MaxBackoff {
+		return q.config.MaxBackoff
+	}
+	return backoff
+}
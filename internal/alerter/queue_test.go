@@ -0,0 +1,246 @@
+package alerter
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fake of the interface DeliveryPersister keeping the deliveries in memory.
+type fakeDeliveries struct {
+	mu          sync.Mutex
+	sequence    uint64
+	pending     map[uint64]persistence.DeliveryEntity
+	deadLetters map[uint64]persistence.DeliveryEntity
+}
+
+func newFakeDeliveries() *fakeDeliveries {
+	return &fakeDeliveries{
+		pending:     make(map[uint64]persistence.DeliveryEntity),
+		deadLetters: make(map[uint64]persistence.DeliveryEntity),
+	}
+}
+
+func (f *fakeDeliveries) InsertDelivery(entity *persistence.DeliveryEntity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if entity.ID == 0 {
+		f.sequence++
+		entity.ID = f.sequence
+	}
+	f.pending[entity.ID] = *entity
+	return nil
+}
+
+func (f *fakeDeliveries) GetAllDeliveries() ([]*persistence.DeliveryEntity, error) {
+	return f.all(f.pending), nil
+}
+
+func (f *fakeDeliveries) DeleteDelivery(id uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, id)
+	return nil
+}
+
+func (f *fakeDeliveries) BuryDelivery(entity *persistence.DeliveryEntity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, entity.ID)
+	f.deadLetters[entity.ID] = *entity
+	return nil
+}
+
+func (f *fakeDeliveries) GetAllDeadLetters() ([]*persistence.DeliveryEntity, error) {
+	return f.all(f.deadLetters), nil
+}
+
+func (f *fakeDeliveries) ReplayDeadLetter(id uint64) (*persistence.DeliveryEntity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entity, ok := f.deadLetters[id]
+	if !ok {
+		return nil, nil
+	}
+	delete(f.deadLetters, id)
+	f.pending[id] = entity
+	return &entity, nil
+}
+
+func (f *fakeDeliveries) all(entities map[uint64]persistence.DeliveryEntity) []*persistence.DeliveryEntity {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var all []*persistence.DeliveryEntity
+	for id := uint64(1); id <= f.sequence; id++ {
+		if entity, ok := entities[id]; ok {
+			all = append(all, &entity)
+		}
+	}
+	return all
+}
+
+func (f *fakeDeliveries) counts() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending), len(f.deadLetters)
+}
+
+// fakeAlerter fails the given number of times before delivering the notifications.
+type fakeAlerter struct {
+	mu       sync.Mutex
+	failures int
+	attempts chan Notification
+}
+
+func newFakeAlerter(failures int) *fakeAlerter {
+	return &fakeAlerter{failures: failures, attempts: make(chan Notification, 16)}
+}
+
+func (f *fakeAlerter) Alert(notification Notification) error {
+	f.attempts <- notification
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("service unavailable")
+	}
+	return nil
+}
+
+var testQueueConfiguration = QueueConfiguration{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+
+func newTestQueue(a Alerter, deliveries persistence.DeliveryPersister) *queue {
+	q := newQueue("ops", a, deliveries, testQueueConfiguration, func(string, error) {})
+	go q.run()
+	return q
+}
+
+// eventually polls the condition until it holds or a second elapsed.
+func eventually(t *testing.T, condition func() bool, msg string) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueueRetriesFailedDeliveries(t *testing.T) {
+	deliveries := newFakeDeliveries()
+	a := newFakeAlerter(2)
+	q := newTestQueue(a, deliveries)
+	defer q.close()
+
+	if err := q.push(newTestWebhookNotification()); err != nil {
+		t.Fatalf("notification should be queued. got: %v\n", err)
+	}
+	for i := 0; i < 3; i++ {
+		if n := receive(t, a.attempts); n.Events[0].Probe.Name != "api" {
+			t.Errorf("queued notification should be attempted. got: %+v\n", n)
+		}
+	}
+	eventually(t, func() bool { return q.size() == 0 }, "delivered notification should leave the queue")
+	if pending, deadLetters := deliveries.counts(); pending != 0 || deadLetters != 0 {
+		t.Errorf("delivered notification should be deleted. got: %d pending, %d dead letters\n", pending, deadLetters)
+	}
+}
+
+func TestQueueBuriesAfterMaxAttempts(t *testing.T) {
+	deliveries := newFakeDeliveries()
+	a := newFakeAlerter(10)
+	q := newTestQueue(a, deliveries)
+	defer q.close()
+
+	if err := q.push(newTestWebhookNotification()); err != nil {
+		t.Fatalf("notification should be queued. got: %v\n", err)
+	}
+	eventually(t, func() bool { _, deadLetters := deliveries.counts(); return deadLetters == 1 }, "notification should be moved to the dead letters")
+	deadLetters, _ := deliveries.GetAllDeadLetters()
+	if deadLetters[0].Attempts != 3 || deadLetters[0].LastError != "service unavailable" {
+		t.Errorf("dead letter should keep the attempts and the last error. got: %+v\n", deadLetters[0])
+	}
+	if len(a.attempts) != 3 || q.size() != 0 {
+		t.Errorf("notification should be attempted 3 times. got: %d\n", len(a.attempts))
+	}
+}
+
+func TestQueueKeepsOrderWhenDeliveryFails(t *testing.T) {
+	a := newFakeAlerter(1)
+	q := newQueue("ops", a, newFakeDeliveries(), testQueueConfiguration, func(string, error) {})
+	for _, status := range []string{prober.DownStatus, prober.UpStatus} {
+		if err := q.push(Notification{Receiver: "ops", Events: []prober.Event{newTestEvent("api", status)}}); err != nil {
+			t.Fatalf("notification should be queued. got: %v\n", err)
+		}
+	}
+	go q.run()
+	defer q.close()
+
+	var statuses []string
+	for i := 0; i < 3; i++ {
+		statuses = append(statuses, receive(t, a.attempts).Events[0].Probe.Status)
+	}
+	if expected := []string{prober.DownStatus, prober.DownStatus, prober.UpStatus}; !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("recovery should wait for the retried outage. got: %v\n", statuses)
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	q := newQueue("ops", nil, nil, QueueConfiguration{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		if backoff := q.backoff(attempts); backoff != expected {
+			t.Errorf("backoff after %d attempts should be %s. got: %s\n", attempts, expected, backoff)
+		}
+	}
+}
+
+func TestServiceRestoresAndReplaysDeliveries(t *testing.T) {
+	deliveries := newFakeDeliveries()
+	data, _ := json.Marshal(Notification{Receiver: "ops", Events: []prober.Event{newTestEvent("restored", prober.DownStatus)}})
+	_ = deliveries.InsertDelivery(&persistence.DeliveryEntity{Receiver: "ops", Notification: data})
+	_ = deliveries.InsertDelivery(&persistence.DeliveryEntity{Receiver: "removed", Notification: data})
+
+	alertBus := make(chan prober.Event)
+	defer close(alertBus)
+	s := newService(alertBus, &fakeSilences{}, deliveries, &Configuration{Queue: testQueueConfiguration})
+	a := newFakeAlerter(3)
+	s.addReceiver("ops", a, nil)
+	s.Run()
+	defer s.Close()
+
+	if n := receive(t, a.attempts); n.Events[0].Probe.Name != "restored" {
+		t.Errorf("pending notification should be restored. got: %+v\n", n)
+	}
+	eventually(t, func() bool { _, deadLetters := deliveries.counts(); return deadLetters == 2 }, "notifications should be moved to the dead letters")
+
+	deadLetters, err := s.DeadLetters()
+	if err != nil || len(deadLetters) != 2 || deadLetters[0].Receiver != "ops" || deadLetters[1].LastError != "receiver [removed] is not available" {
+		t.Fatalf("dead letters should be listed. got: %+v %v\n", deadLetters, err)
+	}
+	if deadLetters[0].Notification.Events[0].Probe.Name != "restored" {
+		t.Errorf("dead letter should hold the notification. got: %+v\n", deadLetters[0].Notification)
+	}
+	if err := s.Replay(deadLetters[1].ID); err != ErrReceiverNotFound {
+		t.Errorf("dead letter of a removed receiver can't be replayed. got: %v\n", err)
+	}
+	if err := s.Replay(42); err != ErrDeadLetterNotFound {
+		t.Errorf("unknown dead letter can't be replayed. got: %v\n", err)
+	}
+
+	for len(a.attempts) > 0 {
+		<-a.attempts
+	}
+	if err := s.Replay(deadLetters[0].ID); err != nil {
+		t.Fatalf("dead letter should be replayed. got: %v\n", err)
+	}
+	receive(t, a.attempts)
+	eventually(t, func() bool { pending, deadLetters := deliveries.counts(); return pending == 0 && deadLetters == 1 }, "replayed notification should be delivered")
+	if metrics := s.Metrics(); metrics[0].Sent != 1 || metrics[0].Failed != 3 {
+		t.Errorf("metrics should count the attempts. got: %+v\n", metrics[0])
+	}
+}
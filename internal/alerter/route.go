@@ -0,0 +1,116 @@
+package alerter
+
+import (
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/labels"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"time"
+)
+
+// Route is a node of the routing tree deciding which receivers get the alerts of a probe.
+// A probe enters a route if it satisfies the selector and has one of the severities (when set).
+// Child routes are then tried in order: the first matching child takes the alert unless it has
+// Continue set, in which case the following children are tried too.
+// If no child matches, the alert is sent to the route's receiver.
+// Grouping options are inherited from the parent route when unset.
+type Route struct {
+	// The receiver alerts are sent to, inherited from the parent route when empty.
+	Receiver string `mapstructure:"receiver"`
+	// Destinations overriding the receiver defaults (e.g. email addresses), inherited along with the receiver.
+	Recipients []string `mapstructure:"recipients"`
+	// Label selector the probe must satisfy, e.g. "team=payments,env!=dev".
+	Selector string `mapstructure:"selector"`
+	// Severities the probe must have, any severity matches when empty.
+	Severity []string `mapstructure:"severity"`
+	// Keep looking for matching siblings after this route matched.
+	Continue bool `mapstructure:"continue"`
+	// Labels used to group probes in a single notification, each probe is notified on its own when empty.
+	GroupBy []string `mapstructure:"group-by"`
+	// How long to wait for other status changes of a group before sending a notification.
+	GroupWait time.Duration `mapstructure:"group-wait"`
+	// How often to remind about the probes that are still DOWN, never when zero.
+	RepeatInterval time.Duration `mapstructure:"repeat-interval"`
+	Routes         []*Route      `mapstructure:"routes"`
+
+	selector labels.Selector
+}
+
+// compile parses the selectors of the route and its children, resolves inherited options
+// and checks that every receiver exists.
+func (r *Route) compile(parent *Route, receivers map[string]bool) error {
+	if parent != nil {
+		r.inherit(parent)
+	}
+	if !receivers[r.Receiver] {
+		return fmt.Errorf("route references unknown receiver [%s]", r.Receiver)
+	}
+	selector, err := labels.Parse(r.Selector)
+	if err != nil {
+		return err
+	}
+	r.selector = selector
+	for _, severity := range r.Severity {
+		if severity == "" || !prober.ValidSeverity(severity) {
+			return fmt.Errorf("route to receiver [%s] has an unknown severity [%s]", r.Receiver, severity)
+		}
+	}
+	for _, child := range r.Routes {
+		if err := child.compile(r, receivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Route) inherit(parent *Route) {
+	if r.Receiver == "" {
+		r.Receiver = parent.Receiver
+		if len(r.Recipients) == 0 {
+			r.Recipients = parent.Recipients
+		}
+	}
+	if len(r.GroupBy) == 0 {
+		r.GroupBy = parent.GroupBy
+	}
+	if r.GroupWait == 0 {
+		r.GroupWait = parent.GroupWait
+	}
+	if r.RepeatInterval == 0 {
+		r.RepeatInterval = parent.RepeatInterval
+	}
+}
+
+func (r *Route) matches(probe prober.Probe) bool {
+	if !r.selector.Matches(probe.Labels) {
+		return false
+	}
+	if len(r.Severity) == 0 {
+		return true
+	}
+	for _, severity := range r.Severity {
+		if severity == probe.EffectiveSeverity() {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the routes handling the alerts of the given probe, depth first.
+// It returns nil when the probe doesn't enter the route.
+func (r *Route) Match(probe prober.Probe) []*Route {
+	if !r.matches(probe) {
+		return nil
+	}
+	var routes []*Route
+	for _, child := range r.Routes {
+		matches := child.Match(probe)
+		routes = append(routes, matches...)
+		if len(matches) > 0 && !child.Continue {
+			break
+		}
+	}
+	if len(routes) == 0 {
+		routes = append(routes, r)
+	}
+	return routes
+}
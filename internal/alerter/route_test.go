@@ -0,0 +1,105 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"reflect"
+	"testing"
+)
+
+func newTestRoute(t *testing.T) *Route {
+	root := &Route{
+		Receiver: "default",
+		Routes: []*Route{
+			{Selector: "team=payments", Receiver: "payments", Continue: true, Routes: []*Route{
+				{Severity: []string{prober.CriticalSeverity}, Receiver: "payments-oncall"},
+			}},
+			{Selector: "team=payments", Receiver: "audit"},
+			{Selector: "team=search", Receiver: "search"},
+			{Severity: []string{prober.WarningSeverity}, Receiver: "warnings"},
+		},
+	}
+	receivers := map[string]bool{"default": true, "payments": true, "payments-oncall": true, "audit": true, "search": true, "warnings": true}
+	if err := root.compile(nil, receivers); err != nil {
+		t.Fatalf("route should compile. got: %v\n", err)
+	}
+	return root
+}
+
+func receiversOf(routes []*Route) []string {
+	var receivers []string
+	for _, r := range routes {
+		receivers = append(receivers, r.Receiver)
+	}
+	return receivers
+}
+
+func TestMatch(t *testing.T) {
+	root := newTestRoute(t)
+	cases := []struct {
+		labels    map[string]string
+		severity  string
+		receivers []string
+	}{
+		{map[string]string{"team": "payments"}, "", []string{"payments-oncall", "audit"}},
+		{map[string]string{"team": "payments"}, prober.WarningSeverity, []string{"payments", "audit"}},
+		{map[string]string{"team": "search"}, prober.WarningSeverity, []string{"search"}},
+		{map[string]string{"team": "infra"}, prober.WarningSeverity, []string{"warnings"}},
+		{nil, prober.InfoSeverity, []string{"default"}},
+	}
+	for _, c := range cases {
+		probe := prober.NewProbe("api", "http://localhost/", 5)
+		probe.Labels = c.labels
+		probe.Severity = c.severity
+		got := receiversOf(root.Match(*probe))
+		if !reflect.DeepEqual(got, c.receivers) {
+			t.Errorf("probe with labels %v and severity [%s] should be routed to %v. got: %v\n", c.labels, c.severity, c.receivers, got)
+		}
+	}
+}
+
+func TestCompileInheritsReceiver(t *testing.T) {
+	root := &Route{Receiver: "default", Routes: []*Route{{Selector: "team=search"}}}
+	if err := root.compile(nil, map[string]bool{"default": true}); err != nil {
+		t.Fatalf("route should compile. got: %v\n", err)
+	}
+	if root.Routes[0].Receiver != "default" {
+		t.Errorf("child route should inherit its parent receiver. got: %s\n", root.Routes[0].Receiver)
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	receivers := map[string]bool{"default": true}
+	cases := []*Route{
+		{Receiver: "unknown"},
+		{Receiver: "default", Routes: []*Route{{Receiver: "unknown"}}},
+		{Receiver: "default", Routes: []*Route{{Selector: "team=pay ments"}}},
+		{Receiver: "default", Routes: []*Route{{Severity: []string{"urgent"}}}},
+	}
+	for _, c := range cases {
+		if err := c.compile(nil, receivers); err == nil {
+			t.Errorf("route should be invalid: %+v\n", c)
+		}
+	}
+}
+
+func TestConfigurationValidate(t *testing.T) {
+	discord := &DiscordConfiguration{ChannelID: "1", Token: "token"}
+	c := &Configuration{
+		Receivers: []ReceiverConfiguration{{Name: "ops", Discord: discord}},
+		Route:     &Route{Receiver: "ops"},
+	}
+	if err := c.validate(); err != nil {
+		t.Errorf("configuration should be valid. got: %v\n", err)
+	}
+
+	c.Receivers = append(c.Receivers, ReceiverConfiguration{Name: "ops", Discord: discord})
+	if err := c.validate(); err == nil {
+		t.Error("receivers declared twice should be invalid")
+	}
+
+	c.Receivers = c.Receivers[:1]
+	c.Route = &Route{Receiver: "ops", Selector: "team=payments"}
+	if err := c.validate(); err == nil {
+		t.Error("root route with a selector should be invalid")
+	}
+}
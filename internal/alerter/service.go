@@ -1,45 +1,197 @@
 package alerter
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/madjlzz/madprobe/internal/persistence"
 	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
+	"log"
+	"sort"
+	"sync"
+	"time"
 )
 
 // Error thrown whenever the alert bus passed to the service is not initialized.
 var ErrAlertBusNotReady = errors.New("bus should be initialized for alerting to work")
 
+// Error thrown whenever a receiver does not exist or could not be started.
+var ErrReceiverNotFound = errors.New("receiver was not found")
+
+// Error thrown whenever a dead letter does not exist.
+var ErrDeadLetterNotFound = errors.New("dead letter was not found")
+
+// AlerterService represent the interface used to inspect the receivers.
+type AlerterService interface {
+	// Preview renders a sample notification with the template of the receiver.
+	Preview(receiver string) (string, error)
+	// Metrics returns the delivery metrics of every receiver sorted by name.
+	Metrics() []ReceiverMetrics
+	// DeadLetters returns the notifications that could not be delivered.
+	DeadLetters() ([]DeadLetter, error)
+	// Replay queues a dead letter again for delivery to its receiver.
+	Replay(id uint64) error
+	// Acknowledge stops the reminders about a DOWN probe until it recovers or the acknowledgement expires.
+	Acknowledge(ack Acknowledgement) error
+	// Acknowledgement returns the active acknowledgement of the probe, nil if there is none.
+	Acknowledgement(probe string) *Acknowledgement
+}
+
+// ReceiverMetrics counts the delivery attempts of a receiver.
+type ReceiverMetrics struct {
+	Name        string
+	Sent        uint64
+	Failed      uint64
+	Pending     int
+	LastError   string
+	LastFailure time.Time
+}
+
+// DeadLetter is a notification given up on after too many failed attempts.
+type DeadLetter struct {
+	ID           uint64
+	Receiver     string
+	Attempts     int
+	LastError    string
+	CreatedAt    time.Time
+	Notification Notification
+}
+
 var instance *service
 
 type service struct {
-	alertBus <-chan prober.Probe
-	alerters []Alerter
+	alertBus  <-chan prober.Event
+	probes    prober.ProbeService
+	silences  silencer.SilenceService
+	route     *Route
+	receivers map[string]Alerter
+	templates map[string]*messageTemplate
+	queues    map[string]*queue
+	// Notifications are persisted until they are delivered.
+	deliveries persistence.DeliveryPersister
+	retry      QueueConfiguration
+	// Escalations of the DOWN probes are persisted until the probes recover.
+	escalations        persistence.EscalationPersister
+	policies           map[string]EscalationPolicy
+	escalationInterval time.Duration
+	// Acknowledgements are persisted until the probes recover.
+	acknowledgements persistence.AcknowledgementPersister
+	done             chan struct{}
+
+	emu        sync.Mutex
+	escalating map[string]*persistence.EscalationEntity
+
+	// Groups are dropped once idle, changes are added to them with the lock held so that none is lost.
+	gmu    sync.Mutex
+	groups map[groupKey]*group
+
+	mu      sync.Mutex
+	metrics map[string]*ReceiverMetrics
+	// Probes currently DOWN and their acknowledgements.
+	down map[string]bool
+	acks map[string]*Acknowledgement
 }
 
-// Initialize the alerting service with existing implementations.
-func NewService(alertBus <-chan prober.Probe) (*service, error) {
+// Initialize the alerting service with the receivers and the routing tree of the configuration.
+// Status changes are grouped per route before being notified,
+// probes muted by an active silence are not notified.
+// Notifications are persisted in a queue per receiver until they are delivered.
+// Receivers taking commands manage probes through the given probe service.
+// DOWN probes having an escalation policy are escalated until they are acknowledged.
+// Acknowledgements are persisted so that they survive restarts.
+// An error is returned if the configuration is invalid.
+func NewService(alertBus <-chan prober.Event, probes prober.ProbeService, silences silencer.SilenceService, deliveries persistence.DeliveryPersister, escalations persistence.EscalationPersister, acknowledgements persistence.AcknowledgementPersister) (*service, error) {
 	if alertBus == nil {
 		return nil, ErrAlertBusNotReady
 	}
-	alerters := []Alerter{NewDiscordAlerter()}
-	instance = &service{
-		alertBus: alertBus,
-		alerters: filter(alerters),
+	c, err := NewConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	if c.Route == nil {
+		log.Println("[WARNING] no receiver has been configured, alerts won't be sent.")
 	}
+	instance = newService(alertBus, silences, deliveries, c)
+	instance.probes = probes
+	instance.escalations = escalations
+	instance.acknowledgements = acknowledgements
+	instance.startReceivers(c)
 	return instance, nil
 }
 
-// Run every alerter that has been correctly instantiated.
+func newService(alertBus <-chan prober.Event, silences silencer.SilenceService, deliveries persistence.DeliveryPersister, c *Configuration) *service {
+	policies := make(map[string]EscalationPolicy)
+	for _, ep := range c.EscalationPolicies {
+		policies[ep.Name] = ep
+	}
+	return &service{
+		alertBus:           alertBus,
+		silences:           silences,
+		route:              c.Route,
+		receivers:          make(map[string]Alerter),
+		templates:          make(map[string]*messageTemplate),
+		queues:             make(map[string]*queue),
+		deliveries:         deliveries,
+		retry:              c.Queue,
+		policies:           policies,
+		done:               make(chan struct{}),
+		escalating:         make(map[string]*persistence.EscalationEntity),
+		escalationInterval: escalationInterval,
+		groups:             make(map[groupKey]*group),
+		metrics:            make(map[string]*ReceiverMetrics),
+		down:               make(map[string]bool),
+		acks:               make(map[string]*Acknowledgement),
+	}
+}
+
+// Run every receiver that has been correctly instantiated.
+// Each receiver gets its own queue so that it only receives the notifications routed to it.
+// Notifications left pending by a previous run are queued first. Those of receivers that are
+// no longer available are moved to the dead letters. Acknowledgements are restored and escalations are resumed.
 func (s *service) Run() {
-	for _, a := range s.alerters {
-		go a.Alert(s.alertBus)
+	s.restoreDeliveries()
+	if s.acknowledgements != nil {
+		s.restoreAcknowledgements()
+	}
+	for _, q := range s.queues {
+		go q.run()
+	}
+	if s.escalations != nil {
+		s.restoreEscalations()
+		go s.runEscalations()
 	}
+	go s.dispatch()
 }
 
-// Close every alerter that can be closed.
+func (s *service) restoreDeliveries() {
+	entities, err := s.deliveries.GetAllDeliveries()
+	if err != nil {
+		log.Printf("[WARNING] pending notifications could not be restored. got: [%v]\n", err)
+		return
+	}
+	for _, entity := range entities {
+		q, ok := s.queues[entity.Receiver]
+		if !ok {
+			entity.LastError = fmt.Sprintf("receiver [%s] is not available", entity.Receiver)
+			if err := s.deliveries.BuryDelivery(entity); err != nil {
+				log.Printf("[WARNING] notification [%d] could not be moved to the dead letters. got: [%v]\n", entity.ID, err)
+			}
+			continue
+		}
+		q.enqueue(entity)
+	}
+}
+
+// Close every queue and every alerter that can be closed.
+// Pending notifications are kept for the next run.
 func (s *service) Close() error {
+	close(s.done)
+	for _, q := range s.queues {
+		q.close()
+	}
 	var err error
-	for _, a := range s.alerters {
+	for _, a := range s.receivers {
 		switch t := a.(type) {
 		case AlertCloser:
 			cErr := t.Close()
@@ -51,12 +203,210 @@ func (s *service) Close() error {
 	return err
 }
 
-func filter(alerters []Alerter) []Alerter {
-	var fAlerters []Alerter
-	for _, a := range alerters {
-		if a != nil {
-			fAlerters = append(fAlerters, a)
+// dispatch adds every event of the alert bus to the groups of the matching routes.
+// A receiver matched by several routes only gets the event once.
+func (s *service) dispatch() {
+	for event := range s.alertBus {
+		s.track(event)
+		s.escalate(event)
+		if s.route == nil {
+			continue
 		}
+		sent := make(map[string]bool)
+		for _, route := range s.route.Match(event.Probe) {
+			if sent[route.Receiver] {
+				continue
+			}
+			sent[route.Receiver] = true
+			s.addToGroup(route, event)
+		}
+	}
+}
+
+// addToGroup adds the event to the aggregation group of its probe on the given route, creating it if needed.
+func (s *service) addToGroup(route *Route, event prober.Event) {
+	labels, key := groupLabels(route, event.Probe)
+	k := groupKey{route: route, labels: key}
+	s.gmu.Lock()
+	defer s.gmu.Unlock()
+	g, ok := s.groups[k]
+	if !ok {
+		g = newGroup(route, labels, s.silences, s.acknowledged, s.notify, func(g *group) { s.releaseGroup(k, g) })
+		s.groups[k] = g
+	}
+	g.add(event)
+}
+
+// releaseGroup drops the idle group unless a status change was added to it in the meantime.
+func (s *service) releaseGroup(key groupKey, g *group) {
+	s.gmu.Lock()
+	defer s.gmu.Unlock()
+	if s.groups[key] == g && g.idle() {
+		delete(s.groups, key)
+	}
+}
+
+// Preview renders a sample notification with the template of the given receiver.
+func (s *service) Preview(receiver string) (string, error) {
+	mt, ok := s.templates[receiver]
+	if !ok {
+		return "", ErrReceiverNotFound
+	}
+	return mt.render(sampleNotification(receiver))
+}
+
+// notify pushes the notification on the queue of its receiver.
+func (s *service) notify(notification Notification) {
+	q, ok := s.queues[notification.Receiver]
+	if !ok {
+		log.Printf("[WARNING] notification dropped, receiver [%s] is not available.\n", notification.Receiver)
+		return
 	}
-	return fAlerters
-}
\ No newline at end of file
+	if err := q.push(notification); err != nil {
+		log.Printf("[WARNING] notification to receiver [%s] dropped, it could not be queued. got: [%v]\n", notification.Receiver, err)
+	}
+}
+
+// DeadLetters returns the notifications that could not be delivered, oldest first.
+func (s *service) DeadLetters() ([]DeadLetter, error) {
+	entities, err := s.deliveries.GetAllDeadLetters()
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]DeadLetter, 0, len(entities))
+	for _, entity := range entities {
+		deadLetter := DeadLetter{
+			ID:        entity.ID,
+			Receiver:  entity.Receiver,
+			Attempts:  entity.Attempts,
+			LastError: entity.LastError,
+			CreatedAt: entity.CreatedAt,
+		}
+		if err := json.Unmarshal(entity.Notification, &deadLetter.Notification); err != nil {
+			log.Printf("[WARNING] dead letter [%d] can't be decoded. got: [%v]\n", entity.ID, err)
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	return deadLetters, nil
+}
+
+// Replay queues a dead letter again for delivery to its receiver, with a fresh number of attempts.
+func (s *service) Replay(id uint64) error {
+	entities, err := s.deliveries.GetAllDeadLetters()
+	if err != nil {
+		return err
+	}
+	var receiver string
+	for _, entity := range entities {
+		if entity.ID == id {
+			receiver = entity.Receiver
+		}
+	}
+	if receiver == "" {
+		return ErrDeadLetterNotFound
+	}
+	q, ok := s.queues[receiver]
+	if !ok {
+		return ErrReceiverNotFound
+	}
+	entity, err := s.deliveries.ReplayDeadLetter(id)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return ErrDeadLetterNotFound
+	}
+	entity.Attempts = 0
+	entity.NextAttempt = time.Time{}
+	if err := s.deliveries.InsertDelivery(entity); err != nil {
+		return err
+	}
+	q.enqueue(entity)
+	return nil
+}
+
+// Metrics returns the delivery metrics of every receiver sorted by name.
+func (s *service) Metrics() []ReceiverMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := make([]ReceiverMetrics, 0, len(s.metrics))
+	for name, m := range s.metrics {
+		metric := *m
+		if q, ok := s.queues[name]; ok {
+			metric.Pending = q.size()
+		}
+		metrics = append(metrics, metric)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+func (s *service) record(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.metrics[name]
+	if err != nil {
+		m.Failed++
+		m.LastError = err.Error()
+		m.LastFailure = time.Now()
+		return
+	}
+	m.Sent++
+}
+
+// startReceivers instantiates the template and the alerter of every receiver.
+// Receivers failing to start are left out.
+func (s *service) startReceivers(c *Configuration) {
+	for _, rc := range c.Receivers {
+		mt, err := newMessageTemplate(rc.Name, rc.template(), c.ExternalURL)
+		if err != nil {
+			log.Printf("[WARNING] receiver [%s] wasn't able to start. got: [%v]\n", rc.Name, err)
+			continue
+		}
+		a, err := newAlerter(rc, mt)
+		if err != nil {
+			log.Printf("[WARNING] receiver [%s] wasn't able to start. got: [%v]\n", rc.Name, err)
+			continue
+		}
+		s.addReceiver(rc.Name, a, mt)
+	}
+}
+
+func (s *service) addReceiver(name string, a Alerter, mt *messageTemplate) {
+	s.receivers[name] = a
+	s.templates[name] = mt
+	s.metrics[name] = &ReceiverMetrics{Name: name}
+	s.queues[name] = newQueue(name, a, s.deliveries, s.retry, s.record)
+	if ack, ok := a.(Acknowledger); ok {
+		ack.OnAcknowledge(s.Acknowledge)
+	}
+	if commander, ok := a.(Commander); ok && s.probes != nil {
+		if err := commander.ServeCommands(s.probes, s.silences); err != nil {
+			log.Printf("[WARNING] receiver [%s] could not serve commands. got: [%v]\n", name, err)
+		}
+	}
+}
+
+func newAlerter(rc ReceiverConfiguration, mt *messageTemplate) (Alerter, error) {
+	switch {
+	case rc.Discord != nil:
+		return NewDiscordAlerter(rc.Discord, mt)
+	case rc.Email != nil:
+		return NewEmailAlerter(rc.Email, mt)
+	case rc.Slack != nil:
+		return NewSlackAlerter(rc.Slack, mt)
+	case rc.Teams != nil:
+		return NewTeamsAlerter(rc.Teams, mt)
+	case rc.PagerDuty != nil:
+		return NewPagerDutyAlerter(rc.PagerDuty, mt)
+	case rc.Opsgenie != nil:
+		return NewOpsgenieAlerter(rc.Opsgenie, mt)
+	case rc.Telegram != nil:
+		return NewTelegramAlerter(rc.Telegram, mt)
+	case rc.Exec != nil:
+		return NewExecAlerter(rc.Exec, mt)
+	}
+	return nil, fmt.Errorf("receiver [%s] has no alerter configured", rc.Name)
+}
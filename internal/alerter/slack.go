@@ -0,0 +1,84 @@
+package alerter
+
+import (
+	"fmt"
+)
+
+// Default template of the Slack messages, written in Slack mrkdwn.
+const slackTemplate = `{{ range .Alerts -}}
+*{{ .Name }}* {{ if .Recovered }}recovered after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }}is still {{ .Status }} for {{ duration .OutageDuration }}{{ else }}is {{ .Status }}{{ end }}{{ if .URL }} - {{ .URL }}{{ end }}
+{{ if .LastError }}{{ if ne .Status "UP" }}> {{ .LastError }}{{ else }}> Last error: {{ .LastError }}{{ end }}
+{{ end }}{{ if .ProbeURL }}<{{ .ProbeURL }}|Details>
+{{ end }}{{ end }}`
+
+// Maximum lengths of the texts of the Slack header and section blocks.
+const (
+	slackHeaderLength  = 150
+	slackSectionLength = 3000
+)
+
+// NewSlackAlerter posts alerts to the configured incoming webhook.
+func NewSlackAlerter(sc *SlackConfiguration, mt *messageTemplate) (*SlackAlerter, error) {
+	return &SlackAlerter{
+		webhookURL: sc.WebhookURL,
+		client:     newWebhookClient(),
+		template:   mt,
+	}, nil
+}
+
+// Alert posts the notification as a Block Kit message
+// whose section is rendered from the receiver template.
+func (sa *SlackAlerter) Alert(notification Notification) error {
+	message, err := slackMessage(notification, sa.template)
+	if err != nil {
+		return err
+	}
+	return postJSON(sa.client, sa.webhookURL, nil, message)
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackPayload is the body of an incoming webhook call.
+// Text is the fallback shown in notifications, blocks are nested in an attachment to get a colored bar.
+type slackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// slackMessage titles the message like the Discord embeds and colors it after the worst status.
+func slackMessage(notification Notification, mt *messageTemplate) (*slackPayload, error) {
+	text, err := mt.render(notification)
+	if err != nil {
+		return nil, err
+	}
+	title := notificationTitle(notification)
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(title, slackHeaderLength)}},
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(text, slackSectionLength)}},
+	}
+	if len(notification.Events) == 1 {
+		date := notification.Events[0].Time.Format("2006-01-02 15:04:05 MST")
+		blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: date}}})
+	}
+	return &slackPayload{
+		Text: title,
+		Attachments: []slackAttachment{{
+			Color:  fmt.Sprintf("#%06X", notificationColor(notification)),
+			Blocks: blocks,
+		}},
+	}, nil
+}
@@ -0,0 +1,117 @@
+package alerter
+
+import (
+	"sort"
+)
+
+// Default template of the Teams messages, written in the markdown subset of Adaptive Cards.
+const teamsTemplate = `{{ range .Alerts -}}
+**{{ .Name }}** {{ if .Recovered }}recovered after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }}is still {{ .Status }} for {{ duration .OutageDuration }}{{ else }}is {{ .Status }}{{ end }}{{ if .URL }} - {{ .URL }}{{ end }}
+{{ if .LastError }}
+{{ if ne .Status "UP" }}_{{ .LastError }}_{{ else }}_Last error: {{ .LastError }}_{{ end }}
+{{ end }}{{ if .ProbeURL }}
+[Details]({{ .ProbeURL }})
+{{ end }}
+{{ end }}`
+
+// Maximum size of the text of a Teams card, the whole message is limited to about 28 KB
+// and the rest is left to the title, the facts and the JSON encoding.
+const teamsTextSize = 24 << 10
+
+// NewTeamsAlerter posts alerts to the configured incoming webhook.
+func NewTeamsAlerter(tc *TeamsConfiguration, mt *messageTemplate) (*TeamsAlerter, error) {
+	return &TeamsAlerter{
+		webhookURL: tc.WebhookURL,
+		client:     newWebhookClient(),
+		template:   mt,
+	}, nil
+}
+
+// Alert posts the notification as an Adaptive Card
+// whose body is rendered from the receiver template.
+func (ta *TeamsAlerter) Alert(notification Notification) error {
+	message, err := teamsMessage(notification, ta.template)
+	if err != nil {
+		return err
+	}
+	return postJSON(ta.client, ta.webhookURL, nil, message)
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+// teamsPayload is the body of an incoming webhook call, a message holding a single Adaptive Card.
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsMessage titles the card like the Discord embeds, colors the title after the worst status
+// and lists the group labels as facts.
+func teamsMessage(notification Notification, mt *messageTemplate) (*teamsPayload, error) {
+	text, err := mt.render(notification)
+	if err != nil {
+		return nil, err
+	}
+	body := []teamsElement{
+		{Type: "TextBlock", Text: notificationTitle(notification), Weight: "Bolder", Size: "Medium", Color: teamsColor(notification), Wrap: true},
+		{Type: "TextBlock", Text: truncateBytes(text, teamsTextSize), Wrap: true},
+	}
+	if len(notification.GroupLabels) > 0 {
+		var facts []teamsFact
+		for name, value := range notification.GroupLabels {
+			facts = append(facts, teamsFact{Title: name, Value: value})
+		}
+		sort.Slice(facts, func(i, j int) bool {
+			return facts[i].Title < facts[j].Title
+		})
+		body = append(body, teamsElement{Type: "FactSet", Facts: facts})
+	}
+	return &teamsPayload{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	}, nil
+}
+
+// teamsColor maps the worst status of the notification to an Adaptive Card color.
+func teamsColor(notification Notification) string {
+	switch notificationColor(notification) {
+	case discordRed:
+		return "Attention"
+	case discordGreen:
+		return "Good"
+	}
+	return "Warning"
+}
@@ -0,0 +1,71 @@
+package alerter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Default endpoint of the Telegram Bot API.
+const defaultTelegramURL = "https://api.telegram.org"
+
+// Maximum length of a Telegram message.
+const telegramMaxLength = 4096
+
+// Default template of the Telegram messages, written in the Telegram legacy Markdown.
+const telegramTemplate = `{{ range .Alerts -}}
+*{{ markdown .Name }}* {{ if .Recovered }}recovered after {{ duration .OutageDuration }} of outage{{ else if $.Repeat }}is still {{ .Status }} for {{ duration .OutageDuration }}{{ else }}is {{ .Status }}{{ end }}{{ if .URL }} - {{ markdown .URL }}{{ end }}
+{{ if .LastError }}{{ if ne .Status "UP" }}_{{ markdown .LastError }}_{{ else }}_Last error: {{ markdown .LastError }}_{{ end }}
+{{ end }}{{ if .ProbeURL }}[Details]({{ .ProbeURL }})
+{{ end }}{{ end }}`
+
+// NewTelegramAlerter posts alerts as the configured bot.
+func NewTelegramAlerter(tc *TelegramConfiguration, mt *messageTemplate) (*TelegramAlerter, error) {
+	return &TelegramAlerter{
+		config:   tc,
+		client:   newWebhookClient(),
+		template: mt,
+	}, nil
+}
+
+// telegramMessage is the body of a sendMessage call.
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// Alert sends the notification to the chats of the route, or to the chats of the receiver when the route has none.
+// The message is titled like the Discord embeds and its body is rendered from the receiver template.
+// When a chat fails, only the chats that did not get the message yet are retried.
+func (ta *TelegramAlerter) Alert(notification Notification) error {
+	chats := notification.Recipients
+	if len(chats) == 0 {
+		chats = ta.config.ChatIDs
+	}
+	if len(chats) == 0 {
+		return errors.New("telegram message has no chat")
+	}
+	text, err := ta.template.render(notification)
+	if err != nil {
+		return err
+	}
+	text = truncate("*"+markdownReplacer.Replace(notificationTitle(notification))+"*\n\n"+text, telegramMaxLength)
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(ta.config.URL, "/"), ta.config.Token)
+	for i, chat := range chats {
+		message := telegramMessage{ChatID: chat, Text: text, ParseMode: "Markdown", DisableWebPagePreview: true}
+		if err := postJSON(ta.client, endpoint, nil, message); err != nil {
+			// The token is part of the endpoint and must not end up in the logs.
+			err = fmt.Errorf("could not send message to chat [%s]: %s", chat, strings.ReplaceAll(err.Error(), ta.config.Token, "<token>"))
+			if i == 0 {
+				return err
+			}
+			// The chats that got the message are not retried.
+			remaining := notification
+			remaining.Recipients = chats[i:]
+			return &partialError{remaining: remaining, err: err}
+		}
+	}
+	return nil
+}
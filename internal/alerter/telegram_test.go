@@ -0,0 +1,122 @@
+package alerter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newFakeBotAPI records the messages sent to the sendMessage method of the bot "token"
+// and rejects the chat "unknown" like the Bot API does.
+func newFakeBotAPI(t *testing.T) (*httptest.Server, chan telegramMessage) {
+	messages := make(chan telegramMessage, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottoken/sendMessage" {
+			http.NotFound(w, r)
+			return
+		}
+		var message telegramMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("message should be JSON. got: %v\n", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if message.ChatID == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		messages <- message
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	return server, messages
+}
+
+func newTestTelegramAlerter(t *testing.T, url string) *TelegramAlerter {
+	tc := &TelegramConfiguration{Token: "token", ChatIDs: []string{"-1001"}, URL: url}
+	if err := tc.validate(); err != nil {
+		t.Fatalf("telegram configuration should be valid. got: %v\n", err)
+	}
+	mt, err := newMessageTemplate("telegram", telegramTemplate, "")
+	if err != nil {
+		t.Fatalf("default telegram template should be valid. got: %v\n", err)
+	}
+	ta, _ := NewTelegramAlerter(tc, mt)
+	return ta
+}
+
+func TestTelegramAlert(t *testing.T) {
+	server, messages := newFakeBotAPI(t)
+	defer server.Close()
+	ta := newTestTelegramAlerter(t, server.URL)
+	notification := newTestWebhookNotification()
+	notification.Events[0].Probe.Name = "billing_api"
+
+	if err := ta.Alert(notification); err != nil {
+		t.Fatalf("message should be sent. got: %v\n", err)
+	}
+	message := <-messages
+	if message.ChatID != "-1001" || message.ParseMode != "Markdown" {
+		t.Errorf("message should be sent in Markdown to the receiver chat. got: %+v\n", message)
+	}
+	expected := "*Probe \\[billing\\_api] is DOWN*\n\n*billing\\_api* is DOWN - http://localhost/health\n_timeout_"
+	if message.Text != expected {
+		t.Errorf("message should escape the probe name. got: %q\n", message.Text)
+	}
+}
+
+func TestTelegramAlertRouteChats(t *testing.T) {
+	server, messages := newFakeBotAPI(t)
+	defer server.Close()
+	ta := newTestTelegramAlerter(t, server.URL)
+	notification := newTestWebhookNotification()
+	notification.Recipients = []string{"1", "2"}
+
+	if err := ta.Alert(notification); err != nil {
+		t.Fatalf("messages should be sent. got: %v\n", err)
+	}
+	if first, second := <-messages, <-messages; first.ChatID != "1" || second.ChatID != "2" {
+		t.Errorf("route chats should override the receiver ones. got: %s %s\n", first.ChatID, second.ChatID)
+	}
+}
+
+func TestTelegramAlertRetriesUndeliveredChats(t *testing.T) {
+	server, messages := newFakeBotAPI(t)
+	defer server.Close()
+	ta := newTestTelegramAlerter(t, server.URL)
+	notification := newTestWebhookNotification()
+	notification.Recipients = []string{"1", "unknown", "2"}
+
+	var partial *partialError
+	if err := ta.Alert(notification); !errors.As(err, &partial) {
+		t.Fatalf("failure after the first chat should be partial. got: %v\n", err)
+	}
+	if !reflect.DeepEqual(partial.remaining.Recipients, []string{"unknown", "2"}) {
+		t.Errorf("only the chats that did not get the message should be retried. got: %v\n", partial.remaining.Recipients)
+	}
+	if message := <-messages; message.ChatID != "1" {
+		t.Errorf("message should be sent to the first chat. got: %s\n", message.ChatID)
+	}
+}
+
+func TestTelegramAlertFailureHidesToken(t *testing.T) {
+	server, _ := newFakeBotAPI(t)
+	defer server.Close()
+	ta := newTestTelegramAlerter(t, server.URL)
+	notification := newTestWebhookNotification()
+	notification.Recipients = []string{"unknown"}
+
+	err := ta.Alert(notification)
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("Bot API error should be reported. got: %v\n", err)
+	}
+
+	server.Close()
+	err = ta.Alert(newTestWebhookNotification())
+	if err == nil || strings.Contains(err.Error(), "bottoken") {
+		t.Errorf("connection error should be reported without the token. got: %v\n", err)
+	}
+}
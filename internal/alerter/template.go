@@ -0,0 +1,186 @@
+package alerter
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/prober"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the context alert message templates are rendered against.
+type TemplateData struct {
+	// The receiver the notification is sent to.
+	Receiver string
+	// Values of the group-by labels shared by the alerts.
+	GroupLabels map[string]string
+	// Set when the notification reminds about probes that are still DOWN.
+	Repeat bool
+	// DOWN when at least one of the probes is DOWN, the status of the first probe otherwise.
+	Status string
+	// Root URL of the madprobe API, empty when not configured.
+	ExternalURL string
+	// The status changes, sorted by probe name.
+	Alerts []AlertData
+}
+
+// AlertData is the status change of a single probe.
+type AlertData struct {
+	Name     string
+	URL      string
+	Severity string
+	Labels   map[string]string
+	// The transition of the probe, from PreviousStatus to Status.
+	Status         string
+	PreviousStatus string
+	// Set when the probe went from DOWN to UP.
+	Recovered bool
+	// When the change has been detected.
+	Time time.Time
+	// When the last outage started, zero if the probe has not been DOWN yet.
+	OutageStart time.Time
+	// How long the outage lasted when the probe recovered, how long it has lasted so far otherwise.
+	OutageDuration time.Duration
+	// Reason and HTTP status code of the last failed check.
+	LastError  string
+	StatusCode int
+	// Link to the probe in the madprobe API, empty when no external URL is configured.
+	ProbeURL string
+}
+
+// Functions available in the templates on top of the text/template builtins.
+var templateFuncs = template.FuncMap{
+	// duration rounds a duration to the second, e.g. "1h2m3s".
+	"duration": func(d time.Duration) string { return d.Round(time.Second).String() },
+	// date formats a time following RFC 1123.
+	"date": func(t time.Time) string { return t.Format(time.RFC1123) },
+	// since returns the duration elapsed since the given time.
+	"since": time.Since,
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// markdown escapes the characters having a meaning in Markdown, e.g. in probe names or errors.
+	"markdown": markdownReplacer.Replace,
+}
+
+var markdownReplacer = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// executor is satisfied by both text and HTML templates.
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// messageTemplate renders the notifications of a receiver into a message.
+type messageTemplate struct {
+	template    executor
+	externalURL string
+}
+
+// newMessageTemplate parses the given text template and checks it renders the sample notification.
+func newMessageTemplate(name, text, externalURL string) (*messageTemplate, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template of receiver [%s] is invalid: %w", name, err)
+	}
+	return checkMessageTemplate(name, t, externalURL)
+}
+
+// newHTMLMessageTemplate parses the given HTML template and checks it renders the sample notification.
+// Values are escaped depending on their context in the document.
+func newHTMLMessageTemplate(name, text, externalURL string) (*messageTemplate, error) {
+	t, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("HTML template of receiver [%s] is invalid: %w", name, err)
+	}
+	return checkMessageTemplate(name, t, externalURL)
+}
+
+func checkMessageTemplate(name string, t executor, externalURL string) (*messageTemplate, error) {
+	mt := &messageTemplate{template: t, externalURL: externalURL}
+	if _, err := mt.render(sampleNotification(name)); err != nil {
+		return nil, fmt.Errorf("template of receiver [%s] can't be rendered: %w", name, err)
+	}
+	return mt, nil
+}
+
+func (mt *messageTemplate) render(notification Notification) (string, error) {
+	var buf bytes.Buffer
+	if err := mt.template.Execute(&buf, newTemplateData(notification, mt.externalURL)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func newTemplateData(notification Notification, externalURL string) TemplateData {
+	data := TemplateData{
+		Receiver:    notification.Receiver,
+		GroupLabels: notification.GroupLabels,
+		Repeat:      notification.Repeat,
+		ExternalURL: externalURL,
+	}
+	for _, event := range notification.Events {
+		probe := event.Probe
+		alert := AlertData{
+			Name:           probe.Name,
+			URL:            probe.URL,
+			Severity:       probe.EffectiveSeverity(),
+			Labels:         probe.Labels,
+			Status:         probe.Status,
+			PreviousStatus: event.PreviousStatus,
+			Recovered:      event.PreviousStatus == prober.DownStatus && probe.Status == prober.UpStatus,
+			Time:           event.Time,
+			OutageStart:    event.OutageStart,
+			OutageDuration: event.OutageDuration,
+			LastError:      probe.LastError,
+			StatusCode:     probe.StatusCode,
+		}
+		if probe.Status == prober.DownStatus && !event.OutageStart.IsZero() {
+			alert.OutageDuration = time.Since(event.OutageStart)
+		}
+		alert.ProbeURL = probeURL(externalURL, probe.Name)
+		if data.Status == "" || probe.Status == prober.DownStatus {
+			data.Status = probe.Status
+		}
+		data.Alerts = append(data.Alerts, alert)
+	}
+	return data
+}
+
+// probeURL links the probe in the madprobe API, it is empty when no external URL is configured.
+func probeURL(externalURL, name string) string {
+	if externalURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(externalURL, "/") + "/api/v1/probe/" + url.PathEscape(name)
+}
+
+// sampleNotification is a notification about a probe going DOWN and another one recovering,
+// used to validate and preview templates.
+func sampleNotification(receiver string) Notification {
+	now := time.Now()
+	down := prober.NewProbe("sample-api", "https://api.example.com/health", 5)
+	down.Labels = map[string]string{"team": "payments", "env": "prod"}
+	down.Status = prober.DownStatus
+	down.StatusCode = 503
+	down.LastError = "unexpected status code 503: service unavailable"
+
+	up := prober.NewProbe("sample-db", "http://db.example.com:8080/health", 5)
+	up.Labels = map[string]string{"team": "payments", "env": "prod"}
+	up.Status = prober.UpStatus
+	up.Since = now.Add(-12 * time.Minute)
+	up.StatusCode = 200
+	up.LastError = "dial tcp 10.0.0.12:8080: connect: connection refused"
+
+	return Notification{
+		Receiver:    receiver,
+		GroupLabels: map[string]string{"team": "payments"},
+		Events: []prober.Event{
+			prober.NewEvent(*down, prober.UpStatus, now),
+			prober.NewEvent(*up, prober.DownStatus, now),
+		},
+	}
+}
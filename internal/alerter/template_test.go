@@ -0,0 +1,49 @@
+package alerter
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"strings"
+	"testing"
+)
+
+func TestNewMessageTemplateInvalid(t *testing.T) {
+	if _, err := newMessageTemplate("ops", "{{ .Alerts", ""); err == nil {
+		t.Error("template that can't be parsed should be invalid")
+	}
+	if _, err := newMessageTemplate("ops", "{{ .Unknown }}", ""); err == nil {
+		t.Error("template referencing an unknown field should be invalid")
+	}
+}
+
+func TestRender(t *testing.T) {
+	text := `{{ .Receiver }} {{ .Status }} {{ .GroupLabels.team }}:{{ range .Alerts }} {{ .Name }}={{ .PreviousStatus }}->{{ .Status }}{{ if .Recovered }} after {{ duration .OutageDuration }}{{ end }} {{ .ProbeURL }}{{ end }}`
+	mt, err := newMessageTemplate("ops", text, "https://madprobe.example.com/")
+	if err != nil {
+		t.Fatalf("template should be valid. got: %v\n", err)
+	}
+	msg, err := mt.render(sampleNotification("ops"))
+	if err != nil {
+		t.Fatalf("template should be rendered. got: %v\n", err)
+	}
+	expected := "ops DOWN payments:" +
+		" sample-api=UP->DOWN https://madprobe.example.com/api/v1/probe/sample-api" +
+		" sample-db=DOWN->UP after 12m0s https://madprobe.example.com/api/v1/probe/sample-db"
+	if msg != expected {
+		t.Errorf("rendered message is invalid.\nwant: %s\n got: %s\n", expected, msg)
+	}
+}
+
+func TestNewTemplateDataStatus(t *testing.T) {
+	n := sampleNotification("ops")
+	if data := newTemplateData(n, ""); data.Status != prober.DownStatus {
+		t.Errorf("status should be DOWN when a probe is down. got: %s\n", data.Status)
+	}
+	n.Events = n.Events[1:]
+	data := newTemplateData(n, "")
+	if data.Status != prober.UpStatus {
+		t.Errorf("status should be UP when every probe is up. got: %s\n", data.Status)
+	}
+	if !strings.HasPrefix(data.Alerts[0].LastError, "dial tcp") || !data.Alerts[0].Recovered {
+		t.Errorf("alert should carry the recovery and the last error. got: %+v\n", data.Alerts[0])
+	}
+}
@@ -0,0 +1,45 @@
+package alerter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Maximum duration of a webhook call.
+const webhookTimeout = 10 * time.Second
+
+func newWebhookClient() *http.Client {
+	return &http.Client{Timeout: webhookTimeout}
+}
+
+// postJSON posts the payload encoded in JSON with the given extra headers, which may be nil,
+// and fails unless the webhook answers with a 2xx status code.
+func postJSON(client *http.Client, url string, header http.Header, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook answered with status code %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package alerter
+
+import (
+	"encoding/json"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newWebhookServer records the JSON bodies posted to it and answers with the given status code.
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, chan map[string]interface{}) {
+	bodies := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook should receive a JSON POST. got: %s %s\n", r.Method, r.Header.Get("Content-Type"))
+		}
+		raw, _ := ioutil.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("webhook body should be JSON. got: %s\n", raw)
+		}
+		bodies <- body
+		w.WriteHeader(status)
+		w.Write([]byte("done"))
+	}))
+	return server, bodies
+}
+
+func newTestWebhookNotification() Notification {
+	probe := prober.NewProbe("api", "http://localhost/health", 5)
+	probe.Status = prober.DownStatus
+	probe.LastError = "timeout"
+	return Notification{
+		Receiver:    "chat",
+		GroupLabels: map[string]string{"team": "payments"},
+		Events:      []prober.Event{prober.NewEvent(*probe, prober.UpStatus, time.Now())},
+	}
+}
+
+// lookup follows the path of map keys and slice indexes in a decoded JSON document.
+func lookup(document interface{}, path ...interface{}) interface{} {
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			m, _ := document.(map[string]interface{})
+			document = m[key]
+		case int:
+			s, _ := document.([]interface{})
+			if key >= len(s) {
+				return nil
+			}
+			document = s[key]
+		}
+	}
+	return document
+}
+
+func TestSlackAlert(t *testing.T) {
+	server, bodies := newWebhookServer(t, http.StatusOK)
+	defer server.Close()
+	mt, err := newMessageTemplate("chat", slackTemplate, "")
+	if err != nil {
+		t.Fatalf("default slack template should be valid. got: %v\n", err)
+	}
+	sa, _ := NewSlackAlerter(&SlackConfiguration{WebhookURL: server.URL}, mt)
+	if err := sa.Alert(newTestWebhookNotification()); err != nil {
+		t.Fatalf("slack message should be posted. got: %v\n", err)
+	}
+
+	body := <-bodies
+	if body["text"] != "Probe [api] is DOWN" {
+		t.Errorf("fallback text should be the title. got: %v\n", body["text"])
+	}
+	if color := lookup(body, "attachments", 0, "color"); color != "#E74C3C" {
+		t.Errorf("attachment should be red. got: %v\n", color)
+	}
+	if header := lookup(body, "attachments", 0, "blocks", 0, "text", "text"); header != "Probe [api] is DOWN" {
+		t.Errorf("header block should hold the title. got: %v\n", header)
+	}
+	expected := "*api* is DOWN - http://localhost/health\n> timeout"
+	if section := lookup(body, "attachments", 0, "blocks", 1, "text", "text"); section != expected {
+		t.Errorf("section block should be rendered from the template. got: %v\n", section)
+	}
+}
+
+func TestTeamsAlert(t *testing.T) {
+	server, bodies := newWebhookServer(t, http.StatusAccepted)
+	defer server.Close()
+	mt, err := newMessageTemplate("chat", teamsTemplate, "")
+	if err != nil {
+		t.Fatalf("default teams template should be valid. got: %v\n", err)
+	}
+	ta, _ := NewTeamsAlerter(&TeamsConfiguration{WebhookURL: server.URL}, mt)
+	if err := ta.Alert(newTestWebhookNotification()); err != nil {
+		t.Fatalf("teams message should be posted. got: %v\n", err)
+	}
+
+	body := <-bodies
+	card := lookup(body, "attachments", 0, "content")
+	if lookup(card, "type") != "AdaptiveCard" {
+		t.Errorf("message should hold an adaptive card. got: %v\n", body)
+	}
+	if title := lookup(card, "body", 0); lookup(title, "text") != "Probe [api] is DOWN" || lookup(title, "color") != "Attention" {
+		t.Errorf("card title should announce the outage in red. got: %v\n", title)
+	}
+	if text, _ := lookup(card, "body", 1, "text").(string); !strings.HasPrefix(text, "**api** is DOWN") {
+		t.Errorf("card text should be rendered from the template. got: %v\n", text)
+	}
+	if fact := lookup(card, "body", 2, "facts", 0); lookup(fact, "title") != "team" || lookup(fact, "value") != "payments" {
+		t.Errorf("card should list the group labels. got: %v\n", fact)
+	}
+}
+
+func TestChatMessagesAreTruncated(t *testing.T) {
+	notification := newTestWebhookNotification()
+	notification.Events[0].Probe.LastError = strings.Repeat("é", 20000)
+
+	mt, _ := newMessageTemplate("chat", slackTemplate, "")
+	slack, err := slackMessage(notification, mt)
+	if err != nil {
+		t.Fatalf("slack message should be rendered. got: %v\n", err)
+	}
+	if section := slack.Attachments[0].Blocks[1].Text.Text; utf8.RuneCountInString(section) != slackSectionLength {
+		t.Errorf("section should be truncated to the Slack limit. got: %d characters\n", utf8.RuneCountInString(section))
+	}
+
+	mt, _ = newMessageTemplate("chat", teamsTemplate, "")
+	teams, err := teamsMessage(notification, mt)
+	if err != nil {
+		t.Fatalf("teams message should be rendered. got: %v\n", err)
+	}
+	if text := teams.Attachments[0].Content.Body[1].Text; len(text) > teamsTextSize || !utf8.ValidString(text) {
+		t.Errorf("card text should be truncated to the Teams limit. got: %d bytes\n", len(text))
+	}
+}
+
+func TestWebhookAlertFailure(t *testing.T) {
+	server, bodies := newWebhookServer(t, http.StatusBadRequest)
+	defer server.Close()
+	mt, _ := newMessageTemplate("chat", slackTemplate, "")
+	sa, _ := NewSlackAlerter(&SlackConfiguration{WebhookURL: server.URL}, mt)
+	err := sa.Alert(newTestWebhookNotification())
+	<-bodies
+	if err == nil || !strings.Contains(err.Error(), "400: done") {
+		t.Errorf("webhook error should be reported. got: %v\n", err)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	if err := validateWebhookURL("https://hooks.slack.com/services/T000/B000/XXXX"); err != nil {
+		t.Errorf("webhook url should be valid. got: %v\n", err)
+	}
+	for _, invalid := range []string{"", "hooks.slack.com/services", "ftp://hooks.slack.com"} {
+		if err := validateWebhookURL(invalid); err == nil {
+			t.Errorf("webhook url [%s] should be invalid\n", invalid)
+		}
+	}
+}
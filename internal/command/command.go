@@ -0,0 +1,65 @@
+// Command contains everything that relates to running the external commands of exec probes and exec receivers.
+package command
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+)
+
+// Run starts the command and waits for it. When the context expires, the command is killed along with
+// the children it started, so that the ones still holding its output pipes don't keep Run waiting.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	isolate(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			kill(cmd)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
+
+// Output captures the first bytes written by a command and discards the rest, so that a command
+// flooding its output can't exhaust the memory.
+type Output struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// NewOutput captures at most limit bytes.
+func NewOutput(limit int) *Output {
+	return &Output{limit: limit}
+}
+
+// Write never fails so that the command isn't stopped by a broken pipe once the limit is reached.
+func (o *Output) Write(p []byte) (int, error) {
+	n := o.limit - o.buf.Len()
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > 0 {
+		o.buf.Write(p[:n])
+	}
+	if n < len(p) {
+		o.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the captured bytes.
+func (o *Output) String() string {
+	return o.buf.String()
+}
+
+// Truncated tells whether bytes were discarded.
+func (o *Output) Truncated() bool {
+	return o.truncated
+}
@@ -0,0 +1,16 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package command
+
+import (
+	"os/exec"
+)
+
+// isolate leaves the command as is, process groups aren't available.
+func isolate(cmd *exec.Cmd) {}
+
+// kill kills the command only, its children are left running.
+func kill(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunKillsChildrenHoldingOutput(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	cmd := exec.Command("/bin/sh", "-c", "sleep 10 & sleep 10")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	start := time.Now()
+	if err := Run(ctx, cmd); err == nil {
+		t.Errorf("killed command should fail\n")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command should be killed with its children once the context expires. got: %s\n", elapsed)
+	}
+}
+
+func TestRunWaitsForCommand(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "echo done")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := Run(context.Background(), cmd); err != nil || stdout.String() != "done\n" {
+		t.Errorf("command should run to completion. got: %q %v\n", stdout.String(), err)
+	}
+}
+
+func TestOutputDiscardsBeyondLimit(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "head -c 1000000 /dev/zero")
+	output := NewOutput(16)
+	cmd.Stdout = output
+	if err := Run(context.Background(), cmd); err != nil {
+		t.Errorf("command should not fail when its output is discarded. got: %v\n", err)
+	}
+	if len(output.String()) != 16 || !output.Truncated() {
+		t.Errorf("output should be cut at the limit. got: %d bytes, truncated %v\n", len(output.String()), output.Truncated())
+	}
+}
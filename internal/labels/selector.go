@@ -0,0 +1,150 @@
+// Labels contains everything that relates to probe labels and label selectors.
+// Selectors are used to filter probes, route alerts and silence probes.
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Keys and values are restricted to a safe set of characters
+// so that they can be written in a selector without escaping.
+var (
+	keyRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?$`)
+	valueRegexp = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9_./-]*[A-Za-z0-9])?)?$`)
+)
+
+type operator string
+
+const (
+	equals       operator = "="
+	notEquals    operator = "!="
+	exists       operator = "exists"
+	doesNotExist operator = "!"
+)
+
+// requirement is a single condition of a selector, e.g. "env!=dev".
+type requirement struct {
+	key      string
+	operator operator
+	value    string
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+	switch r.operator {
+	case equals:
+		return ok && value == r.value
+	case notEquals:
+		return !ok || value != r.value
+	case exists:
+		return ok
+	case doesNotExist:
+		return !ok
+	}
+	return false
+}
+
+func (r requirement) String() string {
+	switch r.operator {
+	case exists:
+		return r.key
+	case doesNotExist:
+		return "!" + r.key
+	}
+	return r.key + string(r.operator) + r.value
+}
+
+// Selector is a comma separated list of requirements that must all be satisfied.
+// Supported requirements are "key=value", "key==value", "key!=value", "key" and "!key".
+// The empty selector matches everything.
+type Selector []requirement
+
+// Parse converts a selector string (e.g. "team=payments,env!=dev") into a Selector.
+func Parse(selector string) (Selector, error) {
+	var s Selector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+func parseRequirement(term string) (requirement, error) {
+	var r requirement
+	switch {
+	case strings.Contains(term, "!="):
+		parts := strings.SplitN(term, "!=", 2)
+		r = requirement{key: parts[0], operator: notEquals, value: parts[1]}
+	case strings.Contains(term, "=="):
+		parts := strings.SplitN(term, "==", 2)
+		r = requirement{key: parts[0], operator: equals, value: parts[1]}
+	case strings.Contains(term, "="):
+		parts := strings.SplitN(term, "=", 2)
+		r = requirement{key: parts[0], operator: equals, value: parts[1]}
+	case strings.HasPrefix(term, "!"):
+		r = requirement{key: term[1:], operator: doesNotExist}
+	default:
+		r = requirement{key: term, operator: exists}
+	}
+	r.key = strings.TrimSpace(r.key)
+	r.value = strings.TrimSpace(r.value)
+	if !keyRegexp.MatchString(r.key) {
+		return r, fmt.Errorf("selector [%s] has an invalid label key [%s]", term, r.key)
+	}
+	if !valueRegexp.MatchString(r.value) {
+		return r, fmt.Errorf("selector [%s] has an invalid label value [%s]", term, r.value)
+	}
+	return r, nil
+}
+
+// Matches tells if the given labels satisfy every requirement of the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty tells if the selector has no requirement and then matches everything.
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, r := range s {
+		terms = append(terms, r.String())
+	}
+	return strings.Join(terms, ",")
+}
+
+// Validate checks that every key and value of the given labels
+// can be used in a selector. Returns the first invalid label found.
+func Validate(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !keyRegexp.MatchString(key) {
+			return fmt.Errorf("label key [%s] is invalid", key)
+		}
+		if !valueRegexp.MatchString(labels[key]) {
+			return fmt.Errorf("label value [%s] of key [%s] is invalid", labels[key], key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package labels
+
+import "testing"
+
+func TestParseAndMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "prod"}
+	cases := map[string]bool{
+		"":                           true,
+		"team=payments":              true,
+		"team==payments":             true,
+		"team=payments,env!=dev":     true,
+		"team=payments, env != prod": false,
+		"team=search":                false,
+		"owner!=alice":               true,
+		"env":                        true,
+		"owner":                      false,
+		"!owner":                     true,
+		"!env":                       false,
+	}
+	for selector, expected := range cases {
+		s, err := Parse(selector)
+		if err != nil {
+			t.Errorf("selector [%s] should be valid. got: %v\n", selector, err)
+			continue
+		}
+		if s.Matches(labels) != expected {
+			t.Errorf("selector [%s] should return [%t] for labels %v\n", selector, expected, labels)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, selector := range []string{"=payments", "team=pay ments", "te am", "!"} {
+		if _, err := Parse(selector); err == nil {
+			t.Errorf("selector [%s] should be invalid\n", selector)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s, _ := Parse("team==payments, env!=dev,!owner,tier")
+	if s.String() != "team=payments,env!=dev,!owner,tier" {
+		t.Errorf("selector string is invalid. got: %s\n", s.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(map[string]string{"team": "payments", "app.kubernetes.io/name": "api"}); err != nil {
+		t.Errorf("labels should be valid. got: %v\n", err)
+	}
+	if err := Validate(map[string]string{"team": "pay,ments"}); err == nil {
+		t.Error("label value with a comma should be invalid")
+	}
+	if err := Validate(map[string]string{"": "payments"}); err == nil {
+		t.Error("empty label key should be invalid")
+	}
+}
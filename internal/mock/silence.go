@@ -0,0 +1,92 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: silence.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	persistence "github.com/madjlzz/madprobe/internal/persistence"
+	reflect "reflect"
+)
+
+// MockSilencePersister is a mock of SilencePersister interface
+type MockSilencePersister struct {
+	ctrl     *gomock.Controller
+	recorder *MockSilencePersisterMockRecorder
+}
+
+// MockSilencePersisterMockRecorder is the mock recorder for MockSilencePersister
+type MockSilencePersisterMockRecorder struct {
+	mock *MockSilencePersister
+}
+
+// NewMockSilencePersister creates a new mock instance
+func NewMockSilencePersister(ctrl *gomock.Controller) *MockSilencePersister {
+	mock := &MockSilencePersister{ctrl: ctrl}
+	mock.recorder = &MockSilencePersisterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSilencePersister) EXPECT() *MockSilencePersisterMockRecorder {
+	return m.recorder
+}
+
+// InsertSilence mocks base method
+func (m *MockSilencePersister) InsertSilence(entity *persistence.SilenceEntity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSilence", entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertSilence indicates an expected call of InsertSilence
+func (mr *MockSilencePersisterMockRecorder) InsertSilence(entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSilence", reflect.TypeOf((*MockSilencePersister)(nil).InsertSilence), entity)
+}
+
+// GetSilence mocks base method
+func (m *MockSilencePersister) GetSilence(name string) (*persistence.SilenceEntity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSilence", name)
+	ret0, _ := ret[0].(*persistence.SilenceEntity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSilence indicates an expected call of GetSilence
+func (mr *MockSilencePersisterMockRecorder) GetSilence(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSilence", reflect.TypeOf((*MockSilencePersister)(nil).GetSilence), name)
+}
+
+// GetAllSilences mocks base method
+func (m *MockSilencePersister) GetAllSilences() ([]*persistence.SilenceEntity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllSilences")
+	ret0, _ := ret[0].([]*persistence.SilenceEntity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllSilences indicates an expected call of GetAllSilences
+func (mr *MockSilencePersisterMockRecorder) GetAllSilences() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllSilences", reflect.TypeOf((*MockSilencePersister)(nil).GetAllSilences))
+}
+
+// DeleteSilence mocks base method
+func (m *MockSilencePersister) DeleteSilence(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSilence", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSilence indicates an expected call of DeleteSilence
+func (mr *MockSilencePersisterMockRecorder) DeleteSilence(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSilence", reflect.TypeOf((*MockSilencePersister)(nil).DeleteSilence), name)
+}
@@ -0,0 +1,24 @@
+package persistence
+
+import (
+	"time"
+)
+
+// Any implementation that wishes to persist the acknowledgements of outages
+// must satisfy the following contract.
+type AcknowledgementPersister interface {
+	// InsertAcknowledgement stores an acknowledgement or replaces the one of the same probe.
+	InsertAcknowledgement(entity *AcknowledgementEntity) error
+	GetAllAcknowledgements() ([]*AcknowledgementEntity, error)
+	DeleteAcknowledgement(probe string) error
+}
+
+// Represent the acknowledgement of the outage of a probe.
+type AcknowledgementEntity struct {
+	Probe   string
+	By      string
+	Comment string
+	At      time.Time
+	// Zero when the acknowledgement lasts until the probe recovers.
+	ExpiresAt time.Time
+}
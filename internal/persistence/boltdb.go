@@ -1,12 +1,18 @@
 package persistence
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"github.com/boltdb/bolt"
 	"github.com/pkg/errors"
 )
 
 const probeBucket = "probe"
+const silenceBucket = "silence"
+const deliveryBucket = "delivery"
+const deadLetterBucket = "dead-letter"
+const escalationBucket = "escalation"
+const acknowledgementBucket = "acknowledgement"
 
 // Implementation of a Persister by using BoltDB
 // as a key/value storage.
@@ -23,8 +29,12 @@ func NewBoltDBClient(filepath string) (*boltDBClient, error) {
 		return nil, errors.Wrap(err, ErrPersisterInitialization.Error())
 	}
 	err = con.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(probeBucket))
-		return err
+		for _, name := range []string{probeBucket, silenceBucket, deliveryBucket, deadLetterBucket, escalationBucket, acknowledgementBucket} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, ErrPersisterInitialization.Error())
@@ -93,3 +103,256 @@ func (c *boltDBClient) GetAll() ([]*Entity, error) {
 	})
 	return entities, err
 }
+
+// InsertSilence stores a new silence inside BoltDB or replaces the one having the same name.
+// Returns nil if there was no errors.
+func (c *boltDBClient) InsertSilence(entity *SilenceEntity) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(silenceBucket))
+		bytes, err := json.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(entity.Name), bytes)
+	})
+	return errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// DeleteSilence delete silence by Name, returns nil error on success.
+func (c *boltDBClient) DeleteSilence(name string) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(silenceBucket))
+		return bucket.Delete([]byte(name))
+	})
+	return errors.Wrap(err, ErrPersisterDeletion.Error())
+}
+
+// GetSilence returns a silence with it's name.
+// Return value can be nil for the entity is nothing is found.
+// An error can be returned if a technical issue occurred.
+func (c *boltDBClient) GetSilence(name string) (*SilenceEntity, error) {
+	var entity *SilenceEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(silenceBucket))
+		silenceBytes := bucket.Get([]byte(name))
+		if len(silenceBytes) == 0 {
+			return nil
+		}
+		entity = &SilenceEntity{}
+		return json.Unmarshal(silenceBytes, entity)
+	})
+	return entity, errors.Wrap(err, ErrPersisterGet.Error())
+}
+
+// GetAllSilences returns all silences from the database or an empty slice if nothing actually stored.
+// An error is returned if any technical error occurs.
+func (c *boltDBClient) GetAllSilences() ([]*SilenceEntity, error) {
+	var entities []*SilenceEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(silenceBucket))
+		return bucket.ForEach(func(_, data []byte) error {
+			var entity SilenceEntity
+			if err := json.Unmarshal(data, &entity); err != nil {
+				return err
+			}
+			entities = append(entities, &entity)
+			return nil
+		})
+	})
+	return entities, errors.Wrap(err, ErrPersisterGet.Error())
+}
+
+// deliveryKey encodes the ID in big endian so that deliveries are iterated in insertion order.
+func deliveryKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+func putDelivery(bucket *bolt.Bucket, entity *DeliveryEntity) error {
+	bytes, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(deliveryKey(entity.ID), bytes)
+}
+
+func getAllDeliveries(bucket *bolt.Bucket) ([]*DeliveryEntity, error) {
+	var entities []*DeliveryEntity
+	err := bucket.ForEach(func(_, data []byte) error {
+		var entity DeliveryEntity
+		if err := json.Unmarshal(data, &entity); err != nil {
+			return err
+		}
+		entities = append(entities, &entity)
+		return nil
+	})
+	return entities, err
+}
+
+// InsertDelivery stores a pending delivery inside BoltDB or replaces the one having the same ID.
+// The ID is taken from the sequence of the delivery bucket when the entity has none.
+// Returns nil if there was no errors.
+func (c *boltDBClient) InsertDelivery(entity *DeliveryEntity) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(deliveryBucket))
+		if entity.ID == 0 {
+			id, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			entity.ID = id
+		}
+		return putDelivery(bucket, entity)
+	})
+	return errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// GetAllDeliveries returns all pending deliveries ordered by ID or an empty slice if nothing actually stored.
+// An error is returned if any technical error occurs.
+func (c *boltDBClient) GetAllDeliveries() ([]*DeliveryEntity, error) {
+	var entities []*DeliveryEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		var err error
+		entities, err = getAllDeliveries(tx.Bucket([]byte(deliveryBucket)))
+		return err
+	})
+	return entities, errors.Wrap(err, ErrPersisterGet.Error())
+}
+
+// DeleteDelivery delete pending delivery by ID, returns nil error on success.
+func (c *boltDBClient) DeleteDelivery(id uint64) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(deliveryBucket)).Delete(deliveryKey(id))
+	})
+	return errors.Wrap(err, ErrPersisterDeletion.Error())
+}
+
+// BuryDelivery moves a pending delivery to the dead letters in a single transaction.
+// Returns nil if there was no errors.
+func (c *boltDBClient) BuryDelivery(entity *DeliveryEntity) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(deliveryBucket)).Delete(deliveryKey(entity.ID)); err != nil {
+			return err
+		}
+		return putDelivery(tx.Bucket([]byte(deadLetterBucket)), entity)
+	})
+	return errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// GetAllDeadLetters returns all dead letters ordered by ID or an empty slice if nothing actually stored.
+// An error is returned if any technical error occurs.
+func (c *boltDBClient) GetAllDeadLetters() ([]*DeliveryEntity, error) {
+	var entities []*DeliveryEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		var err error
+		entities, err = getAllDeliveries(tx.Bucket([]byte(deadLetterBucket)))
+		return err
+	})
+	return entities, errors.Wrap(err, ErrPersisterGet.Error())
+}
+
+// ReplayDeadLetter moves a dead letter back to the pending deliveries in a single transaction.
+// Return value can be nil for the entity is nothing is found.
+// An error can be returned if a technical issue occurred.
+func (c *boltDBClient) ReplayDeadLetter(id uint64) (*DeliveryEntity, error) {
+	var entity *DeliveryEntity
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		deadLetters := tx.Bucket([]byte(deadLetterBucket))
+		data := deadLetters.Get(deliveryKey(id))
+		if len(data) == 0 {
+			return nil
+		}
+		entity = &DeliveryEntity{}
+		if err := json.Unmarshal(data, entity); err != nil {
+			return err
+		}
+		if err := deadLetters.Delete(deliveryKey(id)); err != nil {
+			return err
+		}
+		return putDelivery(tx.Bucket([]byte(deliveryBucket)), entity)
+	})
+	return entity, errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// InsertEscalation stores the escalation of a probe inside BoltDB or replaces the one of the same probe.
+// Returns nil if there was no errors.
+func (c *boltDBClient) InsertEscalation(entity *EscalationEntity) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(escalationBucket))
+		bytes, err := json.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(entity.Probe), bytes)
+	})
+	return errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// DeleteEscalation delete the escalation of a probe, returns nil error on success.
+func (c *boltDBClient) DeleteEscalation(probe string) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(escalationBucket))
+		return bucket.Delete([]byte(probe))
+	})
+	return errors.Wrap(err, ErrPersisterDeletion.Error())
+}
+
+// GetAllEscalations returns all escalations from the database or an empty slice if nothing actually stored.
+// An error is returned if any technical error occurs.
+func (c *boltDBClient) GetAllEscalations() ([]*EscalationEntity, error) {
+	var entities []*EscalationEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(escalationBucket))
+		return bucket.ForEach(func(_, data []byte) error {
+			var entity EscalationEntity
+			if err := json.Unmarshal(data, &entity); err != nil {
+				return err
+			}
+			entities = append(entities, &entity)
+			return nil
+		})
+	})
+	return entities, errors.Wrap(err, ErrPersisterGet.Error())
+}
+
+// InsertAcknowledgement stores the acknowledgement of a probe inside BoltDB or replaces the one of the same probe.
+// Returns nil if there was no errors.
+func (c *boltDBClient) InsertAcknowledgement(entity *AcknowledgementEntity) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(acknowledgementBucket))
+		bytes, err := json.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(entity.Probe), bytes)
+	})
+	return errors.Wrap(err, ErrPersisterInsertion.Error())
+}
+
+// DeleteAcknowledgement delete the acknowledgement of a probe, returns nil error on success.
+func (c *boltDBClient) DeleteAcknowledgement(probe string) error {
+	err := c.boltDB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(acknowledgementBucket))
+		return bucket.Delete([]byte(probe))
+	})
+	return errors.Wrap(err, ErrPersisterDeletion.Error())
+}
+
+// GetAllAcknowledgements returns all acknowledgements from the database or an empty slice if nothing actually stored.
+// An error is returned if any technical error occurs.
+func (c *boltDBClient) GetAllAcknowledgements() ([]*AcknowledgementEntity, error) {
+	var entities []*AcknowledgementEntity
+	err := c.boltDB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(acknowledgementBucket))
+		return bucket.ForEach(func(_, data []byte) error {
+			var entity AcknowledgementEntity
+			if err := json.Unmarshal(data, &entity); err != nil {
+				return err
+			}
+			entities = append(entities, &entity)
+			return nil
+		})
+	})
+	return entities, errors.Wrap(err, ErrPersisterGet.Error())
+}
@@ -0,0 +1,35 @@
+package persistence
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Any implementation that wishes to persist the outbound notifications
+// must satisfy the following contract.
+// Pending deliveries and dead letters share the same IDs.
+type DeliveryPersister interface {
+	// InsertDelivery stores a pending delivery or replaces the one having the same ID.
+	// A new ID is assigned to the entity when it has none.
+	InsertDelivery(entity *DeliveryEntity) error
+	GetAllDeliveries() ([]*DeliveryEntity, error)
+	DeleteDelivery(id uint64) error
+	// BuryDelivery moves a pending delivery to the dead letters.
+	BuryDelivery(entity *DeliveryEntity) error
+	GetAllDeadLetters() ([]*DeliveryEntity, error)
+	// ReplayDeadLetter moves a dead letter back to the pending deliveries.
+	// Return value can be nil if the dead letter is not found.
+	ReplayDeadLetter(id uint64) (*DeliveryEntity, error)
+}
+
+// Represent a notification waiting to be delivered to a receiver, or given up on.
+type DeliveryEntity struct {
+	ID       uint64
+	Receiver string
+	// The notification encoded in JSON.
+	Notification json.RawMessage
+	Attempts     int
+	NextAttempt  time.Time
+	LastError    string
+	CreatedAt    time.Time
+}
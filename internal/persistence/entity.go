@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"encoding/json"
 	"errors"
 	"io"
 )
@@ -34,9 +35,13 @@ type PersistCloser interface {
 
 // Represent the data model that is stored in a file, database, etc...
 type Entity struct {
-	Name  string
-	URL   string
-	Delay uint
+	Name     string
+	URL      string
+	Options  json.RawMessage
+	Delay    uint
+	Labels   map[string]string
+	Severity string
+	Paused   bool
 }
 
 // Simple function that creates an entity given the parameters.
@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Any implementation that wishes to persist the escalation of outages
+// must satisfy the following contract.
+type EscalationPersister interface {
+	// InsertEscalation stores an escalation or replaces the one of the same probe.
+	InsertEscalation(entity *EscalationEntity) error
+	GetAllEscalations() ([]*EscalationEntity, error)
+	DeleteEscalation(probe string) error
+}
+
+// Represent the escalation of the outage of a probe through the tiers of a policy.
+type EscalationEntity struct {
+	Probe  string
+	Policy string
+	// When the probe went DOWN, tiers are notified relatively to it.
+	Since time.Time
+	// Number of tiers already notified.
+	Tiers int
+	// The event of the outage encoded in JSON.
+	Event json.RawMessage
+}
@@ -0,0 +1,24 @@
+package persistence
+
+import "time"
+
+// Any implementation that wishes to persist a silence
+// must satisfy the following contract.
+type SilencePersister interface {
+	InsertSilence(entity *SilenceEntity) error
+	GetSilence(name string) (*SilenceEntity, error)
+	GetAllSilences() ([]*SilenceEntity, error)
+	DeleteSilence(name string) error
+}
+
+// Represent the silence data model that is stored in a file, database, etc...
+type SilenceEntity struct {
+	Name     string
+	Probe    string
+	Selector string
+	Comment  string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Schedule string
+	Duration time.Duration
+}
@@ -0,0 +1,175 @@
+package prober
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// checker performs the checks of a probe.
+type checker interface {
+	check() result
+}
+
+// result is the outcome of a single check.
+type result struct {
+	status string
+	// Status code of the response, when the protocol has one.
+	statusCode int
+	// Reason of the failure, nil when the probe is UP.
+	err error
+	// Measures of the check, e.g. its latency.
+	metrics map[string]float64
+	// Output of the check, when the target describes its health, e.g. the text of a Nagios plugin.
+	message string
+}
+
+// kind describes a type of probe, selected by the scheme of its URL.
+type kind struct {
+	// newChecker builds the checker of a probe, validating its URL and options.
+	// The http client is the one configured for the probes, it is nil during validation.
+	newChecker func(probe Probe, client *http.Client) (checker, error)
+	// Kinds whose URL doesn't need a host.
+	hostless bool
+}
+
+// Kinds of probes by scheme.
+var kinds = map[string]kind{
+	"http":       {newChecker: newHTTPChecker},
+	"https":      {newChecker: newHTTPChecker},
+	"dns":        {newChecker: newDNSChecker, hostless: true},
+	"icmp":       {newChecker: newPingChecker},
+	"grpc":       {newChecker: newGRPCChecker},
+	"grpcs":      {newChecker: newGRPCChecker},
+	"udp":        {newChecker: newUDPChecker},
+	"tcp":        {newChecker: newTCPChecker},
+	"tls":        {newChecker: newTCPChecker},
+	"postgres":   {newChecker: newPostgresChecker},
+	"postgresql": {newChecker: newPostgresChecker},
+	"redis":      {newChecker: newRedisChecker},
+	"rediss":     {newChecker: newRedisChecker},
+	"ws":         {newChecker: newWebSocketChecker},
+	"wss":        {newChecker: newWebSocketChecker},
+	"process":    {newChecker: newProcessChecker, hostless: true},
+	"exec":       {newChecker: newExecChecker, hostless: true},
+	"file":       {newChecker: newFileChecker, hostless: true},
+	"fs":         {newChecker: newFilesystemChecker, hostless: true},
+}
+
+// newChecker builds the checker of the probe according to the scheme of its URL.
+func newChecker(probe Probe, client *http.Client) (checker, error) {
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := kinds[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("scheme [%s] is not supported", u.Scheme)
+	}
+	return k.newChecker(probe, client)
+}
+
+// brokenChecker reports the probe DOWN because its checker could not be built.
+type brokenChecker struct {
+	err error
+}
+
+func (c brokenChecker) check() result {
+	return result{status: DownStatus, err: c.err}
+}
+
+// decodeOptions decodes the JSON options of a probe into v, unknown options are rejected.
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(options) == 0 || string(options) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(options))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("options are invalid: %w", err)
+	}
+	return nil
+}
+
+// tlsConfig returns the TLS settings of the http client of the probes for the given server,
+// so that other protocols trust the same CA certificate. The system roots are trusted otherwise.
+func tlsConfig(client *http.Client, serverName string) *tls.Config {
+	config := &tls.Config{}
+	if client != nil {
+		if transport, ok := client.Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+			config = transport.TLSClientConfig.Clone()
+		}
+	}
+	config.ServerName = serverName
+	return config
+}
+
+// credentialOptions tell where the password of a probe is read, on every check so that it can be rotated.
+// Passwords are never stored with the probes.
+type credentialOptions struct {
+	// Environment variable holding the password.
+	PasswordEnv string
+	// File holding the password, e.g. a mounted Kubernetes secret. Surrounding whitespace is trimmed.
+	PasswordFile string
+}
+
+func (o credentialOptions) validate() error {
+	if o.PasswordEnv != "" && o.PasswordFile != "" {
+		return errors.New("password must be read either from an environment variable or from a file")
+	}
+	return nil
+}
+
+// password returns the password of the probe, empty when none is configured.
+func (o credentialOptions) password() (string, error) {
+	if o.PasswordEnv != "" {
+		password, ok := os.LookupEnv(o.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable [%s] holding the password is not set", o.PasswordEnv)
+		}
+		return password, nil
+	}
+	if o.PasswordFile != "" {
+		password, err := ioutil.ReadFile(o.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read password: %w", err)
+		}
+		return strings.TrimSpace(string(password)), nil
+	}
+	return "", nil
+}
+
+// duration is a time.Duration decoded from a Go duration string in the options, e.g. "500ms".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("duration must be a string, e.g. \"5s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if parsed < 0 {
+		return errors.New("duration must be positive")
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// or returns the duration, or the given default when it is not set.
+func (d duration) or(fallback time.Duration) time.Duration {
+	if d == 0 {
+		return fallback
+	}
+	return time.Duration(d)
+}
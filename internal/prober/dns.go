@@ -0,0 +1,211 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default timeout of a DNS query.
+const defaultDNSTimeout = 5 * time.Second
+
+// Resolver configuration used when the probe doesn't name a resolver.
+const resolvConf = "/etc/resolv.conf"
+
+// Record types a DNS probe can query.
+var dnsTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"CNAME": dns.TypeCNAME,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"SRV":   dns.TypeSRV,
+}
+
+// dnsOptions are the assertions of a DNS probe.
+type dnsOptions struct {
+	// Answers the response must contain, e.g. "10.0.0.1" for A records, "10 mail.example.com" for MX
+	// records or "0 5 5060 sip.example.com" for SRV records.
+	Expect []string
+	// Expected response code, NOERROR by default. At least one answer is required with NOERROR.
+	Rcode string
+	// Timeout of the query, 5s by default.
+	Timeout duration
+}
+
+// dnsChecker queries a resolver for the records of a name, following RFC 4501 URLs:
+// dns://[resolver[:port]]/name?type=A, the resolvers of /etc/resolv.conf are used when none is set.
+type dnsChecker struct {
+	resolver string
+	name     string
+	qtype    uint16
+	rcode    int
+	expect   []string
+	timeout  time.Duration
+}
+
+func newDNSChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options dnsOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	c := &dnsChecker{
+		name:    strings.TrimPrefix(u.Path, "/"),
+		timeout: options.Timeout.or(defaultDNSTimeout),
+	}
+	if c.name == "" {
+		return nil, errors.New("DNS probe URL must name the record to resolve, e.g. dns://8.8.8.8/example.com?type=A")
+	}
+	if u.Host != "" {
+		c.resolver = u.Host
+		if u.Port() == "" {
+			c.resolver = net.JoinHostPort(u.Hostname(), "53")
+		}
+	}
+
+	qtype := strings.ToUpper(u.Query().Get("type"))
+	if qtype == "" {
+		qtype = "A"
+	}
+	var ok bool
+	if c.qtype, ok = dnsTypes[qtype]; !ok {
+		return nil, fmt.Errorf("DNS record type [%s] is not supported", qtype)
+	}
+
+	rcode := strings.ToUpper(options.Rcode)
+	if rcode == "" {
+		rcode = dns.RcodeToString[dns.RcodeSuccess]
+	}
+	if c.rcode, ok = dns.StringToRcode[rcode]; !ok {
+		return nil, fmt.Errorf("DNS response code [%s] is unknown", options.Rcode)
+	}
+
+	for _, expected := range options.Expect {
+		answer, err := normalizeAnswer(c.qtype, expected)
+		if err != nil {
+			return nil, err
+		}
+		c.expect = append(c.expect, answer)
+	}
+	return c, nil
+}
+
+func (c *dnsChecker) check() result {
+	resolver := c.resolver
+	if resolver == "" {
+		config, err := dns.ClientConfigFromFile(resolvConf)
+		if err != nil || len(config.Servers) == 0 {
+			return result{status: DownStatus, err: fmt.Errorf("no resolver is configured in %s: %v", resolvConf, err)}
+		}
+		resolver = net.JoinHostPort(config.Servers[0], config.Port)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(c.name), c.qtype)
+	client := &dns.Client{Timeout: c.timeout}
+	resp, rtt, err := client.Exchange(msg, resolver)
+	if err != nil {
+		return result{status: DownStatus, err: fmt.Errorf("DNS query to [%s] failed: %w", resolver, err)}
+	}
+
+	var answers []string
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == c.qtype {
+			answers = append(answers, formatAnswer(rr))
+		}
+	}
+	metrics := map[string]float64{
+		"latency_seconds": rtt.Seconds(),
+		"answers":         float64(len(answers)),
+	}
+	down := func(err error) result {
+		return result{status: DownStatus, err: err, metrics: metrics}
+	}
+
+	if resp.Rcode != c.rcode {
+		return down(fmt.Errorf("unexpected DNS response code %s, expected %s", dns.RcodeToString[resp.Rcode], dns.RcodeToString[c.rcode]))
+	}
+	if c.rcode == dns.RcodeSuccess && len(answers) == 0 {
+		return down(fmt.Errorf("no %s record found for [%s]", dns.TypeToString[c.qtype], c.name))
+	}
+	for _, expected := range c.expect {
+		if !c.contains(answers, expected) {
+			return down(fmt.Errorf("expected answer [%s] not found in [%s]", expected, strings.Join(answers, ", ")))
+		}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
+
+// formatAnswer returns the data of a record, in the format of the expected answers.
+func formatAnswer(rr dns.RR) string {
+	switch r := rr.(type) {
+	case *dns.A:
+		return r.A.String()
+	case *dns.AAAA:
+		return r.AAAA.String()
+	case *dns.CNAME:
+		return strings.TrimSuffix(r.Target, ".")
+	case *dns.MX:
+		return fmt.Sprintf("%d %s", r.Preference, strings.TrimSuffix(r.Mx, "."))
+	case *dns.TXT:
+		return strings.Join(r.Txt, "")
+	case *dns.SRV:
+		return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, strings.TrimSuffix(r.Target, "."))
+	}
+	return rr.String()
+}
+
+// normalizeAnswer checks an expected answer and puts it in the format of formatAnswer.
+func normalizeAnswer(qtype uint16, answer string) (string, error) {
+	invalid := fmt.Errorf("expected answer [%s] is not a valid %s record", answer, dns.TypeToString[qtype])
+	fields := strings.Fields(answer)
+	switch qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		ip := net.ParseIP(answer)
+		if ip == nil || (qtype == dns.TypeA) != (ip.To4() != nil) {
+			return "", invalid
+		}
+		return ip.String(), nil
+	case dns.TypeCNAME:
+		if len(fields) != 1 {
+			return "", invalid
+		}
+		return strings.TrimSuffix(fields[0], "."), nil
+	case dns.TypeMX, dns.TypeSRV:
+		numbers := 1
+		if qtype == dns.TypeSRV {
+			numbers = 3
+		}
+		if len(fields) != numbers+1 {
+			return "", invalid
+		}
+		for _, field := range fields[:numbers] {
+			if _, err := strconv.ParseUint(field, 10, 16); err != nil {
+				return "", invalid
+			}
+		}
+		fields[numbers] = strings.TrimSuffix(fields[numbers], ".")
+		return strings.Join(fields, " "), nil
+	}
+	return answer, nil
+}
+
+// contains tells if the answers hold the expected one, names are compared regardless of their case.
+func (c *dnsChecker) contains(answers []string, expected string) bool {
+	for _, answer := range answers {
+		if answer == expected || (c.qtype != dns.TypeTXT && strings.EqualFold(answer, expected)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package prober
+
+import (
+	"encoding/json"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"testing"
+)
+
+// startDNSServer serves the given records over UDP on a random local port and returns its address.
+// Names without records are answered with NXDOMAIN.
+func startDNSServer(t *testing.T, records ...string) (string, func()) {
+	zone := make(map[string][]dns.RR)
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatalf("record [%s] should be valid. got: %v\n", record, err)
+		}
+		zone[rr.Header().Name] = append(zone[rr.Header().Name], rr)
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		question := req.Question[0]
+		rrs, ok := zone[question.Name]
+		if !ok {
+			resp.Rcode = dns.RcodeNameError
+		}
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == question.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+		_ = w.WriteMsg(resp)
+	})
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("DNS server should listen. got: %v\n", err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	return conn.LocalAddr().String(), func() { _ = server.Shutdown() }
+}
+
+func newTestDNSChecker(t *testing.T, url string, options string) checker {
+	probe := NewProbe("dns", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("DNS checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestDNSCheckerAssertsAnswers(t *testing.T) {
+	addr, stop := startDNSServer(t,
+		"api.example.com. 60 IN A 10.0.0.1",
+		"api.example.com. 60 IN A 10.0.0.2",
+		"example.com. 60 IN MX 10 mail.example.com.",
+		"example.com. 60 IN TXT \"v=spf1 -all\"",
+		"_sip._udp.example.com. 60 IN SRV 0 5 5060 sip.example.com.",
+		"www.example.com. 60 IN CNAME api.example.com.",
+	)
+	defer stop()
+
+	up := []struct{ url, options string }{
+		{"/api.example.com", `{"Expect": ["10.0.0.2"]}`},
+		{"/api.example.com?type=A", ""},
+		{"/example.com?type=mx", `{"Expect": ["10 MAIL.example.com."]}`},
+		{"/example.com?type=TXT", `{"Expect": ["v=spf1 -all"]}`},
+		{"/_sip._udp.example.com?type=SRV", `{"Expect": ["0 5 5060 sip.example.com"]}`},
+		{"/www.example.com?type=CNAME", `{"Expect": ["api.example.com"]}`},
+		{"/missing.example.com", `{"Rcode": "NXDOMAIN"}`},
+	}
+	for _, probe := range up {
+		res := newTestDNSChecker(t, "dns://"+addr+probe.url, probe.options).check()
+		if res.status != UpStatus {
+			t.Errorf("probe [%s] should be UP. got: %s %v\n", probe.url, res.status, res.err)
+		}
+		if _, ok := res.metrics["latency_seconds"]; !ok {
+			t.Errorf("probe [%s] should measure the latency. got: %v\n", probe.url, res.metrics)
+		}
+	}
+
+	down := []struct{ url, options string }{
+		{"/api.example.com", `{"Expect": ["10.0.0.3"]}`},
+		{"/api.example.com", `{"Rcode": "NXDOMAIN"}`},
+		{"/example.com?type=TXT", `{"Expect": ["V=SPF1 -ALL"]}`},
+		{"/example.com?type=AAAA", ""},
+		{"/missing.example.com", ""},
+	}
+	for _, probe := range down {
+		if res := newTestDNSChecker(t, "dns://"+addr+probe.url, probe.options).check(); res.status != DownStatus || res.err == nil {
+			t.Errorf("probe [%s] should be DOWN with a reason. got: %s\n", probe.url, res.status)
+		}
+	}
+	res := newTestDNSChecker(t, "dns://"+addr+"/missing.example.com", "").check()
+	if !strings.Contains(res.err.Error(), "NXDOMAIN") {
+		t.Errorf("reason should give the response code. got: %v\n", res.err)
+	}
+}
+
+func TestDNSCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"dns://127.0.0.1":                       "",
+		"dns://127.0.0.1/example.com?type=PTR":  "",
+		"dns://127.0.0.1/example.com":           `{"Expect": ["not an ip"]}`,
+		"dns://127.0.0.1/example.com?type=AAAA": `{"Expect": ["10.0.0.1"]}`,
+		"dns://127.0.0.1/example.com?type=MX":   `{"Expect": ["mail.example.com"]}`,
+		"dns:///example.com":                    `{"Rcode": "BROKEN"}`,
+		"dns:///example.com?type=A":             `{"Timeout": 5}`,
+		"dns:///example.com?type=TXT":           `{"Unknown": true}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("dns", url, 5)
+		probe.Options = json.RawMessage(options)
+		if options == "" {
+			probe.Options = nil
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+	if err := runValidators(*NewProbe("dns", "dns:///example.com", 5), urlInvalid, kindInvalid); err != nil {
+		t.Errorf("probe using the system resolver should be valid. got: %v\n", err)
+	}
+}
@@ -0,0 +1,37 @@
+package prober
+
+import "time"
+
+// Event is sent on the alert bus whenever a probe changes status.
+type Event struct {
+	// Snapshot of the probe right after the change.
+	Probe Probe
+	// The status of the probe before the change.
+	PreviousStatus string
+	// When the change has been detected.
+	Time time.Time
+	// When the last outage started, zero if the probe has not been DOWN yet.
+	// For a recovery, it is the start of the outage that just ended.
+	OutageStart time.Time
+	// How long the outage that just ended lasted, zero unless the probe recovered.
+	OutageDuration time.Duration
+}
+
+// NewEvent creates the event of the probe changing from the given previous status.
+// The probe Since must still hold the time at which the previous status started.
+func NewEvent(probe Probe, previousStatus string, now time.Time) Event {
+	event := Event{
+		Probe:          probe,
+		PreviousStatus: previousStatus,
+		Time:           now,
+	}
+	switch {
+	case probe.Status == DownStatus:
+		event.OutageStart = now
+	case previousStatus == DownStatus:
+		event.OutageStart = probe.Since
+		event.OutageDuration = now.Sub(probe.Since)
+	}
+	event.Probe.Since = now
+	return event
+}
@@ -0,0 +1,205 @@
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/command"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Default timeout of the commands of exec probes.
+	defaultExecTimeout = 30 * time.Second
+	// Output of a plugin that is parsed, the rest is discarded.
+	maxExecOutputSize = 64 * 1024
+)
+
+// Directory of the commands exec probes may run, exec probes are disabled when it is empty.
+// Probes are created through the API, so commands are restricted to the plugins installed by the administrator.
+var execPluginsDir string
+
+// EnableExecProbes allows exec probes to run the commands of the given directory.
+func EnableExecProbes(pluginsDir string) {
+	execPluginsDir = pluginsDir
+}
+
+// Statuses of the exit codes of Nagios plugins, other exit codes are UNKNOWN.
+var nagiosStatuses = map[int]string{
+	0: UpStatus,
+	1: DegradedStatus,
+	2: DownStatus,
+	3: UnknownStatus,
+}
+
+// execOptions are the settings of an exec probe.
+type execOptions struct {
+	// Arguments of the command.
+	Args []string
+	// Time after which the command is killed and the probe DOWN, 30s by default.
+	Timeout duration
+}
+
+// execChecker runs a command of the plugins directory, exec:///check_disk, following the Nagios plugin API:
+// the exit code gives the status and the output the message and the performance data.
+type execChecker struct {
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+func newExecChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options execOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	if execPluginsDir == "" {
+		return nil, errors.New("exec probes are disabled, set the directory of the plugins with --exec-plugins-dir")
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host != "" || strings.Trim(u.Path, "/") == "" {
+		return nil, errors.New("exec probe URL must name a command of the plugins directory, e.g. exec:///check_disk")
+	}
+	// Cleaning the path from the root keeps it inside the plugins directory.
+	command := filepath.Join(execPluginsDir, filepath.Clean("/"+u.Path))
+	info, err := os.Stat(command)
+	if err != nil {
+		return nil, fmt.Errorf("command [%s] is not available: %w", u.Path, err)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return nil, fmt.Errorf("command [%s] is not executable", u.Path)
+	}
+	return &execChecker{command: command, args: options.Args, timeout: options.Timeout.or(defaultExecTimeout)}, nil
+}
+
+func (c *execChecker) check() result {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	cmd := exec.Command(c.command, c.args...)
+	stdout, stderr := command.NewOutput(maxExecOutputSize), command.NewOutput(maxExecOutputSize)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := command.Run(ctx, cmd)
+	duration := time.Since(start)
+	if ctx.Err() == context.DeadlineExceeded {
+		return result{status: DownStatus, err: fmt.Errorf("command timed out after %s", c.timeout)}
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return result{status: UnknownStatus, err: fmt.Errorf("command could not be run: %w", err)}
+	}
+
+	summary, message, perfdata := parseNagiosOutput(stdout.String())
+	if summary == "" {
+		summary = strings.TrimSpace(stderr.String())
+		message = summary
+	}
+	metrics := parsePerfdata(perfdata)
+	metrics["latency_seconds"] = duration.Seconds()
+
+	code := cmd.ProcessState.ExitCode()
+	status, ok := nagiosStatuses[code]
+	if !ok {
+		status = UnknownStatus
+		summary = fmt.Sprintf("unexpected exit code %d: %s", code, summary)
+	}
+	res := result{status: status, metrics: metrics, message: message}
+	if status != UpStatus {
+		if summary == "" {
+			summary = fmt.Sprintf("command exited with code %d without output", code)
+		}
+		res.err = errors.New(summary)
+	}
+	return res
+}
+
+// parseNagiosOutput splits the output of a Nagios plugin into its first line, its whole text and its performance data.
+// The first line may end with performance data after a pipe, the following lines give the long text,
+// whose first pipe starts more performance data spanning the remaining lines.
+func parseNagiosOutput(output string) (string, string, string) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	var perfdata []string
+	summary := lines[0]
+	if i := strings.IndexByte(summary, '|'); i >= 0 {
+		perfdata = append(perfdata, summary[i+1:])
+		summary = summary[:i]
+	}
+	summary = strings.TrimSpace(summary)
+	text := []string{summary}
+	for i, line := range lines[1:] {
+		if j := strings.IndexByte(line, '|'); j >= 0 {
+			text = append(text, line[:j])
+			perfdata = append(perfdata, line[j+1:])
+			perfdata = append(perfdata, lines[i+2:]...)
+			break
+		}
+		text = append(text, line)
+	}
+	return summary, strings.TrimSpace(strings.Join(text, "\n")), strings.Join(perfdata, " ")
+}
+
+// A value of performance data: 'label'=value[unit];[warn];[crit];[min];[max], labels with spaces are quoted.
+var perfdataValue = regexp.MustCompile(`('[^']+'|[^'\s=]+)=(-?[0-9.]+(?:[eE][-+]?[0-9]+)?)([a-zA-Z%]*)`)
+
+// Scales of the units of performance data to seconds and bytes.
+var perfdataUnits = map[string]struct {
+	suffix string
+	scale  float64
+}{
+	"s":  {"_seconds", 1},
+	"ms": {"_seconds", 1e-3},
+	"us": {"_seconds", 1e-6},
+	"%":  {"_percent", 1},
+	"b":  {"_bytes", 1},
+	"kb": {"_bytes", 1 << 10},
+	"mb": {"_bytes", 1 << 20},
+	"gb": {"_bytes", 1 << 30},
+	"tb": {"_bytes", 1 << 40},
+	"c":  {"_total", 1},
+}
+
+// Characters replaced in the names of the metrics.
+var metricNameInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// parsePerfdata turns the values of performance data into metrics, e.g. time=0.5ms into time_seconds
+// or /boot=68MB into boot_bytes.
+// Values are converted to seconds and bytes, unknown values "U" are skipped.
+func parsePerfdata(perfdata string) map[string]float64 {
+	metrics := make(map[string]float64)
+	for _, match := range perfdataValue.FindAllStringSubmatch(perfdata, -1) {
+		value, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		label := strings.Trim(match[1], "'")
+		name := strings.Trim(metricNameInvalid.ReplaceAllString(strings.ToLower(label), "_"), "_")
+		if name == "" && strings.HasPrefix(label, "/") {
+			// The root filesystem of check_disk.
+			name = "root"
+		}
+		if name == "" {
+			continue
+		}
+		if unit, ok := perfdataUnits[strings.ToLower(match[3])]; ok {
+			if !strings.HasSuffix(name, unit.suffix) {
+				name += unit.suffix
+			}
+			value *= unit.scale
+		}
+		metrics[name] = value
+	}
+	return metrics
+}
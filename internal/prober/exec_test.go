@@ -0,0 +1,140 @@
+package prober
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withExecPlugins enables exec probes on a directory holding the given shell scripts, by name.
+func withExecPlugins(t *testing.T, scripts map[string]string) func() {
+	dir, err := ioutil.TempDir("", "madprobe-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	EnableExecProbes(dir)
+	return func() {
+		EnableExecProbes("")
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func newTestExecChecker(t *testing.T, url string, options string) checker {
+	probe := NewProbe("exec", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("exec checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestExecCheckerMapsNagiosExitCodes(t *testing.T) {
+	defer withExecPlugins(t, map[string]string{
+		"check_exit": `echo "$2 - exit $1"; exit $1`,
+		"check_disk": `echo "DISK WARNING - free space: / 3326 MB (56%) | /=2643MB;5948;5958;0;5968"
+echo "/ 15272 MB (77%);"
+echo "/boot 68 MB (69%); | /boot=68MB;88;93;0;98"
+echo "'/home dir'=69.5GB;;;0; load=U"
+exit 1`,
+		"check_slow":   `sleep 5`,
+		"check_silent": `echo "no such device" >&2; exit 2`,
+	})()
+
+	statuses := map[string]string{
+		`{"Args": ["0", "OK"]}`:       UpStatus,
+		`{"Args": ["1", "WARNING"]}`:  DegradedStatus,
+		`{"Args": ["2", "CRITICAL"]}`: DownStatus,
+		`{"Args": ["3", "UNKNOWN"]}`:  UnknownStatus,
+		`{"Args": ["42", "WHAT"]}`:    UnknownStatus,
+	}
+	for options, expected := range statuses {
+		res := newTestExecChecker(t, "exec:///check_exit", options).check()
+		if res.status != expected {
+			t.Errorf("probe with options [%s] should be %s. got: %s %v\n", options, expected, res.status, res.err)
+		}
+		if (expected == UpStatus) != (res.err == nil) {
+			t.Errorf("probe with options [%s] should give a reason unless UP. got: %v\n", options, res.err)
+		}
+	}
+	if res := newTestExecChecker(t, "exec:///check_exit", `{"Args": ["0", "OK"]}`).check(); res.message != "OK - exit 0" {
+		t.Errorf("message should be the output of the plugin. got: %q\n", res.message)
+	}
+
+	res := newTestExecChecker(t, "exec:///check_disk", "").check()
+	if res.status != DegradedStatus || res.err.Error() != "DISK WARNING - free space: / 3326 MB (56%)" {
+		t.Errorf("reason should be the first line of the output. got: %s %v\n", res.status, res.err)
+	}
+	if expected := "DISK WARNING - free space: / 3326 MB (56%)\n/ 15272 MB (77%);\n/boot 68 MB (69%);"; res.message != expected {
+		t.Errorf("message should hold the long text. got: %q\n", res.message)
+	}
+	delete(res.metrics, "latency_seconds")
+	expected := map[string]float64{"root_bytes": 2643 << 20, "boot_bytes": 68 << 20, "home_dir_bytes": 69.5 * (1 << 30)}
+	if !reflect.DeepEqual(res.metrics, expected) {
+		t.Errorf("performance data should be converted to metrics. got: %v\n", res.metrics)
+	}
+
+	if res := newTestExecChecker(t, "exec:///check_silent", "").check(); res.status != DownStatus || res.err == nil || res.err.Error() != "no such device" {
+		t.Errorf("reason should fall back to stderr. got: %s %v\n", res.status, res.err)
+	}
+	if res := newTestExecChecker(t, "exec:///check_slow", `{"Timeout": "100ms"}`).check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe whose command times out should be DOWN. got: %s\n", res.status)
+	}
+}
+
+func TestExecCheckerCapsOutput(t *testing.T) {
+	defer withExecPlugins(t, map[string]string{
+		"check_noisy": `echo "NOISY OK"; head -c 1000000 /dev/zero | tr '\\0' a; head -c 1000000 /dev/zero >&2; exit 0`,
+	})()
+
+	res := newTestExecChecker(t, "exec:///check_noisy", "").check()
+	if res.status != UpStatus {
+		t.Errorf("plugin flooding its output should still be checked. got: %s %v\n", res.status, res.err)
+	}
+	if len(res.message) > maxExecOutputSize || !strings.HasPrefix(res.message, "NOISY OK\n") {
+		t.Errorf("output of the plugin should be capped. got: %d bytes\n", len(res.message))
+	}
+}
+
+func TestExecCheckerValidation(t *testing.T) {
+	probe := NewProbe("exec", "exec:///check_exit", 5)
+	if err := kindInvalid(*probe); err == nil {
+		t.Errorf("exec probes should be disabled without plugins directory\n")
+	}
+
+	defer withExecPlugins(t, map[string]string{"check_exit": "exit 0"})()
+	if err := ioutil.WriteFile(filepath.Join(execPluginsDir, "README"), []byte("plugins"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string]string{
+		"exec://":                    "",
+		"exec://check_exit":          "",
+		"exec:///check_missing":      "",
+		"exec:///README":             "",
+		"exec:///../../../../bin/sh": "",
+		"exec:///check_exit?":        `{"Args": "0"}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("exec", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+	if err := runValidators(*NewProbe("exec", "exec:///check_exit", 5), urlInvalid, kindInvalid); err != nil {
+		t.Errorf("probe of an installed plugin should be valid. got: %v\n", err)
+	}
+}
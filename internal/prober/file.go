@@ -0,0 +1,164 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Size of the end of the file its content is matched against.
+const maxContentSize = 1 << 20
+
+// Directory under which file and fs probes and the pidfiles of process probes may look, they are disabled when it is empty.
+// Probes are created through the API, so they are restricted to the paths allowed by the administrator.
+var localProbesRoot string
+
+// EnableLocalProbes allows file and fs probes and the pidfiles of process probes to look under the given directory.
+func EnableLocalProbes(root string) {
+	localProbesRoot = root
+}
+
+// fileOptions are the assertions of a file probe, on the most recently modified file. They are disabled when zero.
+type fileOptions struct {
+	// Age of the last modification from which the probe is DOWN, e.g. "26h" for a daily job.
+	MaxAge duration
+	// Bounds of the size in bytes.
+	MinSize int64
+	MaxSize int64
+	// Regular expression the end of the file must match, its last MiB.
+	Expect string
+}
+
+// fileChecker checks the most recently modified file matching the glob of file:///path URLs,
+// the probe is DOWN when no file matches.
+type fileChecker struct {
+	pattern string
+	options fileOptions
+	expect  *regexp.Regexp
+}
+
+func newFileChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options fileOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	pattern, err := localPath(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("file pattern [%s] is invalid: %w", pattern, err)
+	}
+	if options.MinSize < 0 || options.MaxSize < 0 || (options.MaxSize > 0 && options.MaxSize < options.MinSize) {
+		return nil, errors.New("size bounds must be positive and ordered")
+	}
+	c := &fileChecker{pattern: pattern, options: options}
+	if options.Expect != "" {
+		if c.expect, err = regexp.Compile(options.Expect); err != nil {
+			return nil, fmt.Errorf("expected content is not a valid regular expression: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// localPath returns the absolute path of file:///path and fs:///path URLs, a "?" in the path must be escaped as %3F.
+// The path must be under the root of the local probes.
+func localPath(rawURL string) (string, error) {
+	if localProbesRoot == "" {
+		return "", errors.New("file and fs probes are disabled, set the directory they may look under with --local-probes-root")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Host != "" || !filepath.IsAbs(u.Path) || u.RawQuery != "" || u.ForceQuery || u.Fragment != "" {
+		return "", fmt.Errorf("URL must be an absolute local path, e.g. %s:///var/backups, escape ? as %%3F", u.Scheme)
+	}
+	return underLocalProbesRoot(u.Path)
+}
+
+// underLocalProbesRoot cleans the absolute path and checks that it is under the root of the local probes.
+func underLocalProbesRoot(path string) (string, error) {
+	clean := filepath.Clean(path)
+	if rel, err := filepath.Rel(filepath.Clean(localProbesRoot), clean); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path [%s] is not under the root of the local probes", path)
+	}
+	return clean, nil
+}
+
+func (c *fileChecker) check() result {
+	paths, err := filepath.Glob(c.pattern)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	var newest os.FileInfo
+	var path string
+	files := 0
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files++
+		if newest == nil || info.ModTime().After(newest.ModTime()) {
+			newest, path = info, p
+		}
+	}
+	if newest == nil {
+		return result{status: DownStatus, err: fmt.Errorf("no file matches [%s]", c.pattern), metrics: map[string]float64{"files": 0}}
+	}
+
+	age := time.Since(newest.ModTime())
+	metrics := map[string]float64{
+		"files":       float64(files),
+		"age_seconds": age.Seconds(),
+		"size_bytes":  float64(newest.Size()),
+	}
+	down := func(err error) result {
+		return result{status: DownStatus, err: err, metrics: metrics}
+	}
+	o := c.options
+	if o.MaxAge > 0 && age > time.Duration(o.MaxAge) {
+		return down(fmt.Errorf("file [%s] was last modified %s ago", path, age.Round(time.Second)))
+	}
+	if newest.Size() < o.MinSize || (o.MaxSize > 0 && newest.Size() > o.MaxSize) {
+		return down(fmt.Errorf("file [%s] size of %d bytes is out of bounds", path, newest.Size()))
+	}
+	if c.expect != nil {
+		content, err := readTail(path, maxContentSize)
+		if err != nil {
+			return down(err)
+		}
+		if !c.expect.Match(content) {
+			return down(fmt.Errorf("content of file [%s] doesn't match [%s]", path, c.expect))
+		}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
+
+// readTail returns at most the last size bytes of the file.
+func readTail(path string, size int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > size {
+		if _, err := f.Seek(-size, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	return ioutil.ReadAll(io.LimitReader(f, size))
+}
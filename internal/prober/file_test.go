@@ -0,0 +1,144 @@
+package prober
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withLocalProbes enables file and fs probes under the given directory.
+func withLocalProbes(root string) func() {
+	EnableLocalProbes(root)
+	return func() {
+		EnableLocalProbes("")
+	}
+}
+
+func newTestLocalChecker(t *testing.T, url string, options string) checker {
+	probe := NewProbe("local", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("checker of [%s] should be created. got: %v\n", url, err)
+	}
+	return c
+}
+
+func TestFileCheckerAssertsNewestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madprobe-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer withLocalProbes(dir)()
+	old := filepath.Join(dir, "backup-1.log")
+	recent := filepath.Join(dir, "backup-2.log")
+	if err := ioutil.WriteFile(old, []byte("backup failed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(recent, []byte("dumping...\nbackup completed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err := os.Chtimes(old, lastWeek, lastWeek); err != nil {
+		t.Fatal(err)
+	}
+	url := "file://" + filepath.Join(dir, "backup-*.log")
+
+	up := []string{"", `{"MaxAge": "1h", "MinSize": 1, "MaxSize": 1024, "Expect": "completed\\n$"}`}
+	for _, options := range up {
+		res := newTestLocalChecker(t, url, options).check()
+		if res.status != UpStatus {
+			t.Errorf("probe with options [%s] should be UP. got: %s %v\n", options, res.status, res.err)
+		}
+		if res.metrics["files"] != 2 || res.metrics["size_bytes"] != 28 || res.metrics["age_seconds"] > 60 {
+			t.Errorf("probe should measure the newest file. got: %v\n", res.metrics)
+		}
+	}
+
+	down := []struct{ url, options string }{
+		{url, `{"MaxAge": "1ns"}`},
+		{url, `{"MinSize": 100}`},
+		{url, `{"MaxSize": 10}`},
+		{"file://" + filepath.Join(dir, "backup-1.*"), `{"Expect": "completed"}`},
+		{"file://" + filepath.Join(dir, "*.gz"), ""},
+	}
+	for _, probe := range down {
+		if res := newTestLocalChecker(t, probe.url, probe.options).check(); res.status != DownStatus || res.err == nil {
+			t.Errorf("probe [%s] with options [%s] should be DOWN with a reason. got: %s\n", probe.url, probe.options, res.status)
+		}
+	}
+}
+
+func TestFilesystemCheckerAssertsFreeSpace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madprobe-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer withLocalProbes(dir)()
+	url := "fs://" + dir
+
+	res := newTestLocalChecker(t, url, "").check()
+	if res.status != UpStatus {
+		t.Fatalf("probe without thresholds should be UP. got: %s %v\n", res.status, res.err)
+	}
+	if res.metrics["size_bytes"] <= 0 || res.metrics["free_percent"] > 100 {
+		t.Errorf("probe should measure the filesystem. got: %v\n", res.metrics)
+	}
+	if res := newTestLocalChecker(t, url, `{"DegradedFreePercent": 100}`).check(); res.status != DegradedStatus || res.err == nil {
+		t.Errorf("probe below the DEGRADED threshold should be DEGRADED. got: %s\n", res.status)
+	}
+	if res := newTestLocalChecker(t, url, `{"DegradedFreePercent": 100, "DownFreePercent": 100}`).check(); res.status != DownStatus {
+		t.Errorf("probe below the DOWN threshold should be DOWN. got: %s\n", res.status)
+	}
+	if res := newTestLocalChecker(t, "fs://"+filepath.Join(dir, "missing"), "").check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe of a missing path should be DOWN. got: %s\n", res.status)
+	}
+}
+
+func TestLocalCheckersValidation(t *testing.T) {
+	if err := kindInvalid(*NewProbe("local", "fs:///", 5)); err == nil {
+		t.Errorf("local probes should be disabled without root\n")
+	}
+	defer withLocalProbes("/var")()
+	for _, url := range []string{"fs:///", "fs:///data", "file:///var/../etc/passwd", "file:///variable/*"} {
+		if err := kindInvalid(*NewProbe("local", url, 5)); err == nil {
+			t.Errorf("probe [%s] outside the root should be invalid\n", url)
+		}
+	}
+
+	EnableLocalProbes("/")
+	invalid := map[string]string{
+		"file://host/var/log":     "",
+		"file://var/log":          "",
+		"file:///var/log/?.log":   "",
+		"file:///var/log/[.log":   "",
+		"file:///var/log/app.log": `{"MinSize": 10, "MaxSize": 5}`,
+		"file:///var/log/job.log": `{"Expect": "("}`,
+		"fs://":                   "",
+		"fs:///data":              `{"DownFreePercent": 120}`,
+		"fs:///data?":             "",
+		"fs:///var":               `{"DegradedFreePercent": 10, "DownFreePercent": 20}`,
+		"fs:///var/lib":           `{"DegradedFreeInodesPercent": 5, "DownFreeInodesPercent": 10}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("local", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+	for _, url := range []string{"file:///var/backups/db-%3F.sql.gz", "fs:///"} {
+		if err := runValidators(*NewProbe("local", url, 5), urlInvalid, kindInvalid); err != nil {
+			t.Errorf("probe [%s] should be valid. got: %v\n", url, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// filesystemOptions are the thresholds of a filesystem probe, in percent of free space and free inodes.
+// The probe is DEGRADED or DOWN below them, they are disabled when zero.
+type filesystemOptions struct {
+	DegradedFreePercent       float64
+	DownFreePercent           float64
+	DegradedFreeInodesPercent float64
+	DownFreeInodesPercent     float64
+}
+
+// filesystemStat holds the usage of a filesystem. Inodes are 0 on filesystems without a fixed number of inodes.
+type filesystemStat struct {
+	size, free         uint64
+	inodes, freeInodes uint64
+}
+
+// filesystemChecker measures the free space and inodes of the filesystem mounted on the path of fs:///path URLs.
+// Free space is the space available to unprivileged users.
+type filesystemChecker struct {
+	path    string
+	options filesystemOptions
+}
+
+func newFilesystemChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options filesystemOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	path, err := localPath(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	o := options
+	for _, threshold := range []float64{o.DegradedFreePercent, o.DownFreePercent, o.DegradedFreeInodesPercent, o.DownFreeInodesPercent} {
+		if threshold < 0 || threshold > 100 {
+			return nil, errors.New("free space thresholds must be percentages")
+		}
+	}
+	if (o.DegradedFreePercent > 0 && o.DownFreePercent > o.DegradedFreePercent) ||
+		(o.DegradedFreeInodesPercent > 0 && o.DownFreeInodesPercent > o.DegradedFreeInodesPercent) {
+		return nil, errors.New("DOWN thresholds must be below the DEGRADED ones")
+	}
+	return &filesystemChecker{path: path, options: options}, nil
+}
+
+func (c *filesystemChecker) check() result {
+	stat, err := statFilesystem(c.path)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	if stat.size == 0 {
+		return result{status: DownStatus, err: fmt.Errorf("filesystem of [%s] has no size", c.path)}
+	}
+	free := 100 * float64(stat.free) / float64(stat.size)
+	metrics := map[string]float64{
+		"size_bytes":   float64(stat.size),
+		"free_bytes":   float64(stat.free),
+		"free_percent": free,
+	}
+	// Inode thresholds are ignored on filesystems allocating inodes dynamically.
+	freeInodes := 100.0
+	if stat.inodes > 0 {
+		freeInodes = 100 * float64(stat.freeInodes) / float64(stat.inodes)
+		metrics["inodes"] = float64(stat.inodes)
+		metrics["free_inodes"] = float64(stat.freeInodes)
+		metrics["free_inodes_percent"] = freeInodes
+	}
+
+	o := c.options
+	summary := fmt.Sprintf("%.1f%% of space and %.1f%% of inodes free on [%s]", free, freeInodes, c.path)
+	switch {
+	case free < o.DownFreePercent || freeInodes < o.DownFreeInodesPercent:
+		return result{status: DownStatus, err: errors.New(summary), metrics: metrics}
+	case free < o.DegradedFreePercent || freeInodes < o.DegradedFreeInodesPercent:
+		return result{status: DegradedStatus, err: errors.New(summary), metrics: metrics}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
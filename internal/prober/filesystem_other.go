@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package prober
+
+import "errors"
+
+// statFilesystem isn't supported on this platform.
+func statFilesystem(path string) (filesystemStat, error) {
+	return filesystemStat{}, errors.New("filesystem probes are not supported on this platform")
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package prober
+
+import "syscall"
+
+// statFilesystem returns the usage of the filesystem holding the path.
+func statFilesystem(path string) (filesystemStat, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return filesystemStat{}, err
+	}
+	return filesystemStat{
+		size:       uint64(fs.Blocks) * uint64(fs.Bsize),
+		free:       uint64(fs.Bavail) * uint64(fs.Bsize),
+		inodes:     uint64(fs.Files),
+		freeInodes: uint64(fs.Ffree),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package prober
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Default timeout of a gRPC health check.
+const defaultGRPCTimeout = 5 * time.Second
+
+// grpcOptions are the settings of a gRPC probe.
+type grpcOptions struct {
+	// Name of the service whose health is checked, the health of the whole server when empty.
+	Service string
+	// Timeout of the connection and of the call, 5s by default.
+	Timeout duration
+}
+
+// grpcChecker calls the Check method of the grpc.health.v1.Health service of grpc://host:port URLs in plaintext,
+// or of grpcs://host:port URLs over TLS trusting the CA certificate of the probes.
+type grpcChecker struct {
+	target    string
+	service   string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+}
+
+func newGRPCChecker(probe Probe, client *http.Client) (checker, error) {
+	var options grpcOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("gRPC probe URL must only name a host and a port, e.g. grpc://localhost:50051")
+	}
+	c := &grpcChecker{
+		target:  u.Host,
+		service: options.Service,
+		timeout: options.Timeout.or(defaultGRPCTimeout),
+	}
+	if u.Scheme == "grpcs" {
+		c.tlsConfig = tlsConfig(client, u.Hostname())
+	}
+	return c, nil
+}
+
+func (c *grpcChecker) check() result {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	credential := grpc.WithInsecure()
+	if c.tlsConfig != nil {
+		credential = grpc.WithTransportCredentials(credentials.NewTLS(c.tlsConfig))
+	}
+	start := time.Now()
+	conn, err := grpc.DialContext(ctx, c.target, credential, grpc.WithBlock())
+	if err != nil {
+		return result{status: DownStatus, err: fmt.Errorf("could not connect to [%s]: %w", c.target, err)}
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: c.service})
+	metrics := map[string]float64{"latency_seconds": time.Since(start).Seconds()}
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			err = fmt.Errorf("service [%s] is unknown to the server", c.service)
+		}
+		return result{status: DownStatus, err: err, metrics: metrics}
+	}
+	switch resp.Status {
+	case grpc_health_v1.HealthCheckResponse_SERVING:
+		return result{status: UpStatus, metrics: metrics}
+	case grpc_health_v1.HealthCheckResponse_UNKNOWN:
+		return result{status: UnknownStatus, err: errors.New("server doesn't know the health of the service"), metrics: metrics}
+	}
+	return result{status: DownStatus, err: fmt.Errorf("service is %s", resp.Status), metrics: metrics}
+}
@@ -0,0 +1,107 @@
+package prober
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// startGRPCServer serves the standard health service on a random local port, over TLS when a config is given.
+func startGRPCServer(t *testing.T, tlsConfig *tls.Config) (string, *health.Server, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("gRPC server should listen. got: %v\n", err)
+	}
+	var options []grpc.ServerOption
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(options...)
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	return listener.Addr().String(), healthServer, server.Stop
+}
+
+func newTestGRPCChecker(t *testing.T, url string, options string, client *http.Client) checker {
+	probe := NewProbe("grpc", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, client)
+	if err != nil {
+		t.Fatalf("gRPC checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestGRPCCheckerMapsServingStatus(t *testing.T) {
+	addr, healthServer, stop := startGRPCServer(t, nil)
+	defer stop()
+	healthServer.SetServingStatus("payments", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus("ledger", grpc_health_v1.HealthCheckResponse_UNKNOWN)
+
+	statuses := map[string]string{
+		"":                        UpStatus,
+		`{"Service": "payments"}`: DownStatus,
+		`{"Service": "ledger"}`:   UnknownStatus,
+		`{"Service": "missing"}`:  DownStatus,
+	}
+	for options, expected := range statuses {
+		res := newTestGRPCChecker(t, "grpc://"+addr, options, nil).check()
+		if res.status != expected {
+			t.Errorf("probe with options [%s] should be %s. got: %s %v\n", options, expected, res.status, res.err)
+		}
+		if expected != UpStatus && res.err == nil {
+			t.Errorf("probe with options [%s] should give a reason\n", options)
+		}
+		if _, ok := res.metrics["latency_seconds"]; !ok {
+			t.Errorf("probe with options [%s] should measure the latency. got: %v\n", options, res.metrics)
+		}
+	}
+
+	stop()
+	if res := newTestGRPCChecker(t, "grpc://"+addr, `{"Timeout": "200ms"}`, nil).check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe should be DOWN when the server is stopped. got: %s\n", res.status)
+	}
+}
+
+func TestGRPCCheckerTrustsCACertificate(t *testing.T) {
+	tlsConfig, client := newTLSTestConfig()
+	addr, _, stop := startGRPCServer(t, tlsConfig)
+	defer stop()
+
+	if res := newTestGRPCChecker(t, "grpcs://"+addr, "", client).check(); res.status != UpStatus {
+		t.Errorf("probe trusting the CA should be UP. got: %s %v\n", res.status, res.err)
+	}
+	if res := newTestGRPCChecker(t, "grpcs://"+addr, `{"Timeout": "500ms"}`, http.DefaultClient).check(); res.status != DownStatus {
+		t.Errorf("probe not trusting the CA should be DOWN. got: %s\n", res.status)
+	}
+	if res := newTestGRPCChecker(t, "grpc://"+addr, `{"Timeout": "500ms"}`, client).check(); res.status != DownStatus {
+		t.Errorf("plaintext probe of a TLS server should be DOWN. got: %s\n", res.status)
+	}
+}
+
+func TestGRPCCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"grpc://127.0.0.1":          "",
+		"grpcs://127.0.0.1:443/foo": "",
+		"grpc://127.0.0.1:50051":    `{"Timeout": 5}`,
+		"grpc://localhost:50051":    `{"Unknown": true}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("grpc", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package prober
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Size of the beginning of the body of a failed response reported in the error.
+const maxErrorBodySize = 4 << 10
+
+// httpChecker checks that a GET request on the URL of the probe returns 200.
+type httpChecker struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPChecker(probe Probe, client *http.Client) (checker, error) {
+	var options struct{}
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	return &httpChecker{url: probe.URL, client: client}, nil
+}
+
+func (c *httpChecker) check() result {
+	start := time.Now()
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	_ = resp.Body.Close()
+	metrics := map[string]float64{"latency_seconds": time.Since(start).Seconds()}
+	if resp.StatusCode != 200 {
+		return result{
+			status:     DownStatus,
+			statusCode: resp.StatusCode,
+			err:        fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(b)),
+			metrics:    metrics,
+		}
+	}
+	return result{status: UpStatus, statusCode: resp.StatusCode, metrics: metrics}
+}
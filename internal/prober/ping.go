@@ -0,0 +1,194 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Defaults of the ping probes.
+const (
+	defaultPingCount    = 3
+	defaultPingInterval = 100 * time.Millisecond
+	defaultPingTimeout  = time.Second
+)
+
+// Protocol number of ICMP for IPv4, used to parse the replies.
+const protocolICMP = 1
+
+// pingOptions are the settings of a ping probe. Thresholds are disabled when zero,
+// the probe is DOWN when every packet is lost.
+type pingOptions struct {
+	// Echo requests sent on every check, 3 by default.
+	Count int
+	// Delay between two echo requests, 100ms by default.
+	Interval duration
+	// How long to wait for each reply, 1s by default.
+	Timeout duration
+	// Uses a raw socket, which requires privileges, instead of an unprivileged datagram socket.
+	Privileged bool
+	// Packet loss in percent from which the probe is DEGRADED or DOWN.
+	DegradedLoss float64
+	DownLoss     float64
+	// Average round-trip time from which the probe is DEGRADED or DOWN.
+	DegradedRTT duration
+	DownRTT     duration
+}
+
+// pingChecker sends ICMP echo requests to the host of icmp://host URLs, IPv4 only.
+// Datagram ICMP sockets are used by default, they are allowed to the groups of net.ipv4.ping_group_range on Linux.
+type pingChecker struct {
+	host    string
+	options pingOptions
+}
+
+func newPingChecker(probe Probe, _ *http.Client) (checker, error) {
+	options := pingOptions{Count: defaultPingCount}
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() != "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("ping probe URL must only name a host, e.g. icmp://192.0.2.1")
+	}
+	if options.Count <= 0 {
+		return nil, errors.New("count of packets must be at least 1")
+	}
+	for _, loss := range []float64{options.DegradedLoss, options.DownLoss} {
+		if loss < 0 || loss > 100 {
+			return nil, errors.New("packet loss thresholds must be percentages")
+		}
+	}
+	if options.DownLoss == 0 {
+		options.DownLoss = 100
+	}
+	if options.DegradedLoss > options.DownLoss ||
+		(options.DownRTT > 0 && options.DegradedRTT > options.DownRTT) {
+		return nil, errors.New("DEGRADED thresholds must be below the DOWN ones")
+	}
+	return &pingChecker{host: u.Hostname(), options: options}, nil
+}
+
+func (c *pingChecker) check() result {
+	addr, err := net.ResolveIPAddr("ip4", c.host)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	rtts, err := c.ping(addr)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+
+	o := c.options
+	loss := 100 * float64(o.Count-len(rtts)) / float64(o.Count)
+	metrics := map[string]float64{
+		"packets_sent":     float64(o.Count),
+		"packets_received": float64(len(rtts)),
+		"loss_percent":     loss,
+	}
+	var avg time.Duration
+	if len(rtts) > 0 {
+		min, max, sum, jitter := rtts[0], rtts[0], time.Duration(0), time.Duration(0)
+		for i, rtt := range rtts {
+			sum += rtt
+			if rtt < min {
+				min = rtt
+			}
+			if rtt > max {
+				max = rtt
+			}
+			if i > 0 {
+				jitter += time.Duration(math.Abs(float64(rtt - rtts[i-1])))
+			}
+		}
+		avg = sum / time.Duration(len(rtts))
+		metrics["rtt_min_seconds"] = min.Seconds()
+		metrics["rtt_avg_seconds"] = avg.Seconds()
+		metrics["rtt_max_seconds"] = max.Seconds()
+		// Jitter is the mean difference between consecutive round-trip times.
+		if len(rtts) > 1 {
+			jitter /= time.Duration(len(rtts) - 1)
+		}
+		metrics["jitter_seconds"] = jitter.Seconds()
+	}
+
+	summary := fmt.Sprintf("%.0f%% packet loss, %s average round-trip time", loss, avg)
+	switch {
+	case loss >= o.DownLoss || (o.DownRTT > 0 && avg >= time.Duration(o.DownRTT)):
+		return result{status: DownStatus, err: errors.New(summary), metrics: metrics}
+	case (o.DegradedLoss > 0 && loss >= o.DegradedLoss) || (o.DegradedRTT > 0 && avg >= time.Duration(o.DegradedRTT)):
+		return result{status: DegradedStatus, err: errors.New(summary), metrics: metrics}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
+
+// ping sends the echo requests one after the other and returns the round-trip times of the replies received.
+func (c *pingChecker) ping(addr *net.IPAddr) ([]time.Duration, error) {
+	network, dst := "udp4", net.Addr(&net.UDPAddr{IP: addr.IP})
+	if c.options.Privileged {
+		network, dst = "ip4:icmp", addr
+	}
+	conn, err := icmp.ListenPacket(network, "0.0.0.0")
+	if err != nil {
+		return nil, fmt.Errorf("could not open ICMP socket: %w", err)
+	}
+	defer conn.Close()
+
+	// The kernel sets the ID of datagram sockets, raw sockets see the replies of every process.
+	id := rand.Intn(math.MaxUint16)
+	timeout := c.options.Timeout.or(defaultPingTimeout)
+	var rtts []time.Duration
+	for seq := 0; seq < c.options.Count; seq++ {
+		if seq > 0 {
+			time.Sleep(c.options.Interval.or(defaultPingInterval))
+		}
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("madprobe")},
+		}
+		data, err := msg.Marshal(nil)
+		if err != nil {
+			return nil, err
+		}
+		sent := time.Now()
+		if _, err := conn.WriteTo(data, dst); err != nil {
+			return nil, fmt.Errorf("could not send echo request: %w", err)
+		}
+		if c.receive(conn, id, seq, sent.Add(timeout)) {
+			rtts = append(rtts, time.Since(sent))
+		}
+	}
+	return rtts, nil
+}
+
+// receive waits for the echo reply of the given sequence until the deadline.
+func (c *pingChecker) receive(conn *icmp.PacketConn, id, seq int, deadline time.Time) bool {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return false
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return false
+		}
+		msg, err := icmp.ParseMessage(protocolICMP, buf[:n])
+		if err != nil || msg.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := msg.Body.(*icmp.Echo)
+		if ok && echo.Seq == seq && (!c.options.Privileged || echo.ID == id) {
+			return true
+		}
+	}
+}
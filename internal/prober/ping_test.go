@@ -0,0 +1,81 @@
+package prober
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/icmp"
+	"testing"
+)
+
+// pingPrivileges returns the Privileged option allowed to the tests, skipping them when ICMP sockets can't be opened.
+func pingPrivileges(t *testing.T) bool {
+	for _, privileged := range []bool{false, true} {
+		network := "udp4"
+		if privileged {
+			network = "ip4:icmp"
+		}
+		if conn, err := icmp.ListenPacket(network, "127.0.0.1"); err == nil {
+			_ = conn.Close()
+			return privileged
+		}
+	}
+	t.Skip("ICMP sockets are not permitted, see net.ipv4.ping_group_range")
+	return false
+}
+
+func newTestPingChecker(t *testing.T, privileged bool, options string) checker {
+	probe := NewProbe("ping", "icmp://127.0.0.1", 5)
+	probe.Options = json.RawMessage(fmt.Sprintf(`{"Count": 3, "Interval": "10ms", "Privileged": %t%s}`, privileged, options))
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("ping checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestPingCheckerMeasuresRoundTrips(t *testing.T) {
+	privileged := pingPrivileges(t)
+
+	res := newTestPingChecker(t, privileged, "").check()
+	if res.status != UpStatus {
+		t.Fatalf("localhost should answer pings. got: %s %v\n", res.status, res.err)
+	}
+	if res.metrics["packets_received"] != 3 || res.metrics["loss_percent"] != 0 {
+		t.Errorf("every packet should be received. got: %v\n", res.metrics)
+	}
+	for _, metric := range []string{"rtt_min_seconds", "rtt_avg_seconds", "rtt_max_seconds", "jitter_seconds"} {
+		if _, ok := res.metrics[metric]; !ok {
+			t.Errorf("metric [%s] should be measured. got: %v\n", metric, res.metrics)
+		}
+	}
+	if res.metrics["rtt_min_seconds"] > res.metrics["rtt_avg_seconds"] || res.metrics["rtt_avg_seconds"] > res.metrics["rtt_max_seconds"] {
+		t.Errorf("round-trip times should be ordered. got: %v\n", res.metrics)
+	}
+
+	if res := newTestPingChecker(t, privileged, `, "DegradedRTT": "1ns"`).check(); res.status != DegradedStatus {
+		t.Errorf("probe should be DEGRADED above the RTT threshold. got: %s\n", res.status)
+	}
+	if res := newTestPingChecker(t, privileged, `, "DegradedRTT": "1ns", "DownRTT": "2ns"`).check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe should be DOWN above the RTT threshold. got: %s\n", res.status)
+	}
+}
+
+func TestPingCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"icmp://127.0.0.1:80":  "",
+		"icmp://127.0.0.1/foo": "",
+		"icmp://127.0.0.1":     `{"Count": 0}`,
+		"icmp://localhost":     `{"DownLoss": 120}`,
+		"icmp://192.0.2.1":     `{"DegradedLoss": 50, "DownLoss": 20}`,
+		"icmp://192.0.2.2":     `{"DegradedRTT": "500ms", "DownRTT": "100ms"}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("ping", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package prober
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Default timeout of a PostgreSQL check, from the connection to the result of the query.
+const defaultPostgresTimeout = 5 * time.Second
+
+// postgresOptions are the settings of a PostgreSQL probe.
+type postgresOptions struct {
+	credentialOptions
+	// Query run once connected, "SELECT 1" by default. It must return a single value.
+	Query string
+	// Text the value returned by the query must equal, any value is accepted by default.
+	Expect *string
+	// Timeout of the check, 5s by default.
+	Timeout duration
+}
+
+// postgresChecker connects to postgres://user@host[:port]/database URLs, authenticates and runs a query.
+// Connection parameters such as sslmode or sslrootcert are given in the query string of the URL.
+type postgresChecker struct {
+	url         url.URL
+	credentials credentialOptions
+	query       string
+	expect      *string
+	timeout     time.Duration
+}
+
+func newPostgresChecker(probe Probe, _ *http.Client) (checker, error) {
+	options := postgresOptions{Query: "SELECT 1"}
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := u.User.Password(); ok || u.Query().Get("password") != "" {
+		return nil, errors.New("password must not be written in the URL, use the PasswordEnv or PasswordFile options")
+	}
+	// Validates the connection parameters of the URL.
+	if _, err := pq.NewConnector(u.String()); err != nil {
+		return nil, err
+	}
+	return &postgresChecker{
+		url:         *u,
+		credentials: options.credentialOptions,
+		query:       options.Query,
+		expect:      options.Expect,
+		timeout:     options.Timeout.or(defaultPostgresTimeout),
+	}, nil
+}
+
+func (c *postgresChecker) check() result {
+	password, err := c.credentials.password()
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	u := c.url
+	if password != "" {
+		u.User = url.UserPassword(u.User.Username(), password)
+	}
+	connector, err := pq.NewConnector(u.String())
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	start := time.Now()
+	var value sql.NullString
+	if err := db.QueryRowContext(ctx, c.query).Scan(&value); err != nil {
+		return result{status: DownStatus, err: fmt.Errorf("query [%s] failed: %w", c.query, err)}
+	}
+	metrics := map[string]float64{"latency_seconds": time.Since(start).Seconds()}
+	if c.expect != nil && (!value.Valid || value.String != *c.expect) {
+		got := "NULL"
+		if value.Valid {
+			got = value.String
+		}
+		return result{status: DownStatus, err: fmt.Errorf("query [%s] returned [%s], expected [%s]", c.query, got, *c.expect), metrics: metrics}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
@@ -1,11 +1,20 @@
 package prober
 
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
 // ProbeService represent the interface used to manipulate probes.
 type ProbeService interface {
 	Insert(probe Probe) error
 	Get(name string) (*Probe, error)
 	GetAll() ([]*Probe, error)
 	Delete(name string) error
+	// Pause stops checking the probe until it is resumed, the probe keeps its last status.
+	Pause(name string) error
+	Resume(name string) error
 }
 
 // TODO: we need a solution to decouple Run() from the package prober so that it becomes independent.
@@ -14,13 +23,41 @@ type ProbeRunner interface {
 	Run(probe *Probe)
 }
 
+// Severities a probe can be given, used to route its alerts.
+const (
+	CriticalSeverity = "critical"
+	WarningSeverity  = "warning"
+	InfoSeverity     = "info"
+)
+
 // Probe is the model required by the service to manipulate the resource.
+// The scheme of the URL selects the kind of the probe, e.g. http or dns,
+// Options holds the settings specific to the kind in JSON.
+// Labels are arbitrary key/value pairs used to select probes.
+// An empty Severity stands for CriticalSeverity.
 type Probe struct {
-	Name   string
-	URL    string
-	Status string
-	Delay  uint
-	Finish chan bool
+	Name     string
+	URL      string
+	Options  json.RawMessage
+	Status   string
+	Delay    uint
+	Labels   map[string]string
+	Severity string
+	// When the probe entered its current status.
+	Since time.Time
+	// Reason of the last failed check, kept after the probe recovers.
+	LastError string
+	// HTTP status code returned by the last check, 0 if no response was received.
+	StatusCode int
+	// Paused probes are not checked. Shared by the copies of the probe and accessed atomically
+	// as probes are paused while their runner checks them.
+	paused *int32
+	// Measures of the last check by name, e.g. latency_seconds.
+	Metrics map[string]float64
+	// Output of the last check, e.g. the text of a Nagios plugin.
+	Message string
+	// Stops the checks of the probe, not part of the serialized probe.
+	Finish chan bool `json:"-"`
 }
 
 // Creates a new Probe with the given parameters.
@@ -29,6 +66,32 @@ func NewProbe(name, URL string, delay uint) *Probe {
 		Name:   name,
 		URL:    URL,
 		Delay:  delay,
+		paused: new(int32),
 		Finish: make(chan bool),
 	}
 }
+
+// Paused returns true if the probe is not checked until it is resumed.
+func (p *Probe) Paused() bool {
+	return p.paused != nil && atomic.LoadInt32(p.paused) == 1
+}
+
+// SetPaused pauses or resumes the checks of the probe.
+func (p *Probe) SetPaused(paused bool) {
+	if p.paused == nil {
+		p.paused = new(int32)
+	}
+	var value int32
+	if paused {
+		value = 1
+	}
+	atomic.StoreInt32(p.paused, value)
+}
+
+// EffectiveSeverity returns the severity of the probe, CriticalSeverity if none has been set.
+func (p Probe) EffectiveSeverity() string {
+	if p.Severity == "" {
+		return CriticalSeverity
+	}
+	return p.Severity
+}
@@ -1,6 +1,9 @@
 package prober
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestNewProbe(t *testing.T) {
 	probe := NewProbe("TheProbe", "TheURL", 5)
@@ -20,3 +23,36 @@ func TestNewProbe(t *testing.T) {
 		t.Errorf("Finish channel should be initialized. got %v\n", probe.Finish)
 	}
 }
+
+func TestNewEventOnOutage(t *testing.T) {
+	now := time.Now()
+	probe := NewProbe("TheProbe", "TheURL", 5)
+	probe.Status = DownStatus
+	probe.Since = now.Add(-time.Hour)
+
+	event := NewEvent(*probe, UpStatus, now)
+	if event.PreviousStatus != UpStatus {
+		t.Errorf("PreviousStatus property should be [UP]. got: %s\n", event.PreviousStatus)
+	}
+	if !event.OutageStart.Equal(now) || event.OutageDuration != 0 {
+		t.Errorf("outage should start now and have no duration. got: %v %v\n", event.OutageStart, event.OutageDuration)
+	}
+	if !event.Probe.Since.Equal(now) {
+		t.Errorf("probe snapshot should be in its new status since now. got: %v\n", event.Probe.Since)
+	}
+}
+
+func TestNewEventOnRecovery(t *testing.T) {
+	now := time.Now()
+	probe := NewProbe("TheProbe", "TheURL", 5)
+	probe.Status = UpStatus
+	probe.Since = now.Add(-time.Hour)
+
+	event := NewEvent(*probe, DownStatus, now)
+	if !event.OutageStart.Equal(probe.Since) {
+		t.Errorf("outage should have started when the probe went down. got: %v\n", event.OutageStart)
+	}
+	if event.OutageDuration != time.Hour {
+		t.Errorf("outage should have lasted an hour. got: %v\n", event.OutageDuration)
+	}
+}
@@ -0,0 +1,242 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Root of the proc filesystem the processes are read from.
+const procRoot = "/proc"
+
+// Clock ticks per second of the CPU times of /proc/[pid]/stat, USER_HZ is 100 on every Linux architecture.
+const clockTicks = 100
+
+// processOptions select the processes of a process probe, exactly one selector is required.
+// Thresholds are disabled when zero.
+type processOptions struct {
+	// Process ID.
+	Pid int
+	// File holding the process ID, read on every check. It must be under the root of the local probes.
+	Pidfile string
+	// Regular expression the name of the processes must match, e.g. "^nginx$".
+	Name string
+	// Regular expression the command line of the processes must match, arguments are separated by spaces.
+	Cmdline string
+	// Resident memory of the processes in MiB from which the probe is DEGRADED.
+	DegradedRSSMiB float64
+	// CPU usage of the processes in percent of one core from which the probe is DEGRADED.
+	DegradedCPU float64
+}
+
+// processSample is the CPU time of a process at a given time.
+type processSample struct {
+	ticks uint64
+	at    time.Time
+}
+
+// processChecker looks for local processes in /proc, the probe is DOWN when none is running.
+// CPU usage is measured between two checks.
+type processChecker struct {
+	options processOptions
+	name    *regexp.Regexp
+	cmdline *regexp.Regexp
+	// CPU times of the processes at the previous check, by PID.
+	samples map[int]processSample
+}
+
+func newProcessChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options processOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host != "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("process probes only check the local host, their URL is process://")
+	}
+	selectors := 0
+	for _, set := range []bool{options.Pid != 0, options.Pidfile != "", options.Name != "", options.Cmdline != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return nil, errors.New("process must be selected by exactly one of Pid, Pidfile, Name or Cmdline")
+	}
+	if options.Pid < 0 {
+		return nil, errors.New("PID must be positive")
+	}
+	// The pidfile is read like the files of the file probes, it is restricted to the root of the local probes.
+	if options.Pidfile != "" {
+		if localProbesRoot == "" {
+			return nil, errors.New("pidfiles are disabled, set the directory they may be read under with --local-probes-root")
+		}
+		if !filepath.IsAbs(options.Pidfile) {
+			return nil, errors.New("pidfile must be an absolute path")
+		}
+		if options.Pidfile, err = underLocalProbesRoot(options.Pidfile); err != nil {
+			return nil, err
+		}
+	}
+	c := &processChecker{options: options, samples: make(map[int]processSample)}
+	if options.Name != "" {
+		if c.name, err = regexp.Compile(options.Name); err != nil {
+			return nil, fmt.Errorf("process name is not a valid regular expression: %w", err)
+		}
+	}
+	if options.Cmdline != "" {
+		if c.cmdline, err = regexp.Compile(options.Cmdline); err != nil {
+			return nil, fmt.Errorf("process command line is not a valid regular expression: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *processChecker) check() result {
+	pids, err := c.pids()
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+
+	now := time.Now()
+	samples := make(map[int]processSample)
+	var rss, cpu float64
+	measuredCPU := false
+	for _, pid := range pids {
+		stat, err := readProcessStat(pid)
+		if err != nil || stat.state == "Z" {
+			continue
+		}
+		rss += float64(stat.rssPages * uint64(os.Getpagesize()))
+		samples[pid] = processSample{ticks: stat.ticks, at: now}
+		if previous, ok := c.samples[pid]; ok && stat.ticks >= previous.ticks && now.After(previous.at) {
+			cpu += 100 * float64(stat.ticks-previous.ticks) / clockTicks / now.Sub(previous.at).Seconds()
+			measuredCPU = true
+		}
+	}
+	c.samples = samples
+	if len(samples) == 0 {
+		return result{status: DownStatus, err: fmt.Errorf("no process %s is running", c.selector())}
+	}
+
+	metrics := map[string]float64{"processes": float64(len(samples)), "rss_bytes": rss}
+	if measuredCPU {
+		metrics["cpu_percent"] = cpu
+	}
+	o := c.options
+	switch {
+	case o.DegradedRSSMiB > 0 && rss >= o.DegradedRSSMiB*1024*1024:
+		return result{status: DegradedStatus, err: fmt.Errorf("processes use %.1f MiB of memory", rss/1024/1024), metrics: metrics}
+	case o.DegradedCPU > 0 && measuredCPU && cpu >= o.DegradedCPU:
+		return result{status: DegradedStatus, err: fmt.Errorf("processes use %.1f%% of CPU", cpu), metrics: metrics}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
+
+// pids returns the IDs of the candidate processes, not necessarily running.
+func (c *processChecker) pids() ([]int, error) {
+	switch {
+	case c.options.Pid != 0:
+		return []int{c.options.Pid}, nil
+	case c.options.Pidfile != "":
+		data, err := ioutil.ReadFile(c.options.Pidfile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read pidfile: %w", err)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("pidfile [%s] doesn't hold a PID", c.options.Pidfile)
+		}
+		return []int{pid}, nil
+	}
+
+	dirs, err := filepath.Glob(filepath.Join(procRoot, "[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		if c.name != nil {
+			if stat, err := readProcessStat(pid); err == nil && c.name.MatchString(stat.name) {
+				pids = append(pids, pid)
+			}
+			continue
+		}
+		cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+		if err == nil && len(cmdline) > 0 && c.cmdline.Match(cmdlineText(cmdline)) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// selector describes how the processes are selected, for the errors.
+func (c *processChecker) selector() string {
+	switch {
+	case c.options.Pid != 0:
+		return fmt.Sprintf("with PID %d", c.options.Pid)
+	case c.options.Pidfile != "":
+		return fmt.Sprintf("of pidfile [%s]", c.options.Pidfile)
+	case c.name != nil:
+		return fmt.Sprintf("named [%s]", c.name)
+	}
+	return fmt.Sprintf("with command line [%s]", c.cmdline)
+}
+
+// processStat holds the fields of /proc/[pid]/stat used by the probes.
+type processStat struct {
+	name     string
+	state    string
+	ticks    uint64
+	rssPages uint64
+}
+
+// readProcessStat parses /proc/[pid]/stat, see proc(5).
+func readProcessStat(pid int) (processStat, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return processStat{}, err
+	}
+	// The name is between parentheses and may contain spaces or parentheses itself.
+	stat := string(data)
+	open, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return processStat{}, fmt.Errorf("stat of process %d is malformed", pid)
+	}
+	// Fields from the state (3rd) onwards.
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return processStat{}, fmt.Errorf("stat of process %d is malformed", pid)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	rss, _ := strconv.ParseUint(fields[21], 10, 64)
+	return processStat{name: stat[open+1 : end], state: fields[0], ticks: utime + stime, rssPages: rss}, nil
+}
+
+// cmdlineText turns the NUL separated arguments of /proc/[pid]/cmdline into a space separated command line.
+func cmdlineText(cmdline []byte) []byte {
+	text := make([]byte, len(cmdline))
+	for i, b := range cmdline {
+		if b == 0 {
+			b = ' '
+		}
+		text[i] = b
+	}
+	return []byte(strings.TrimSpace(string(text)))
+}
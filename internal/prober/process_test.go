@@ -0,0 +1,113 @@
+package prober
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func newTestProcessChecker(t *testing.T, options string) checker {
+	probe := NewProbe("process", "process://", 5)
+	probe.Options = json.RawMessage(options)
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("process checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestProcessCheckerFindsProcesses(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("/proc is not available")
+	}
+	defer withLocalProbes("/")()
+	self, err := readProcessStat(os.Getpid())
+	if err != nil {
+		t.Fatalf("stat of the test process should be read. got: %v\n", err)
+	}
+	pidfile, err := ioutil.TempFile("", "madprobe-pid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(pidfile.Name())
+	_, _ = fmt.Fprintf(pidfile, "%d\n", os.Getpid())
+	_ = pidfile.Close()
+
+	up := []string{
+		fmt.Sprintf(`{"Pid": %d}`, os.Getpid()),
+		fmt.Sprintf(`{"Pidfile": %q}`, pidfile.Name()),
+		fmt.Sprintf(`{"Name": %q}`, "^"+regexp.QuoteMeta(self.name)+"$"),
+		fmt.Sprintf(`{"Cmdline": %q}`, regexp.QuoteMeta(os.Args[0])),
+	}
+	for _, options := range up {
+		res := newTestProcessChecker(t, options).check()
+		if res.status != UpStatus {
+			t.Errorf("probe with options [%s] should be UP. got: %s %v\n", options, res.status, res.err)
+		}
+		if res.metrics["processes"] < 1 || res.metrics["rss_bytes"] <= 0 {
+			t.Errorf("probe with options [%s] should measure the processes. got: %v\n", options, res.metrics)
+		}
+	}
+
+	c := newTestProcessChecker(t, fmt.Sprintf(`{"Pid": %d, "DegradedCPU": 100000}`, os.Getpid()))
+	if res := c.check(); res.status != UpStatus {
+		t.Errorf("probe below the CPU threshold should be UP. got: %s %v\n", res.status, res.err)
+	}
+	if res := c.check(); res.status != UpStatus {
+		t.Errorf("probe below the CPU threshold should be UP. got: %s %v\n", res.status, res.err)
+	} else if _, ok := res.metrics["cpu_percent"]; !ok {
+		t.Errorf("CPU usage should be measured from the second check. got: %v\n", res.metrics)
+	}
+	if res := newTestProcessChecker(t, fmt.Sprintf(`{"Pid": %d, "DegradedRSSMiB": 0.001}`, os.Getpid())).check(); res.status != DegradedStatus || res.err == nil {
+		t.Errorf("probe above the memory threshold should be DEGRADED. got: %s\n", res.status)
+	}
+
+	down := []string{
+		`{"Pid": 99999999}`,
+		`{"Pidfile": "/nonexistent/madprobe.pid"}`,
+		`{"Name": "^madprobe-no-such-process$"}`,
+	}
+	for _, options := range down {
+		if res := newTestProcessChecker(t, options).check(); res.status != DownStatus || res.err == nil {
+			t.Errorf("probe with options [%s] should be DOWN with a reason. got: %s\n", options, res.status)
+		}
+	}
+}
+
+func TestProcessCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"process://":         `{}`,
+		"process:///":        `{"Pid": 1, "Name": "init"}`,
+		"process://host":     `{"Pid": 1}`,
+		"process:///usr/bin": `{"Pid": 1}`,
+		"process://?":        `{"Pid": -1}`,
+		"process://#":        `{"Cmdline": "("}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("process", url, 5)
+		probe.Options = json.RawMessage(options)
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+
+	probe := NewProbe("process", "process://", 5)
+	probe.Options = json.RawMessage(`{"Pidfile": "/run/nginx.pid"}`)
+	if err := kindInvalid(*probe); err == nil {
+		t.Errorf("pidfiles should be disabled without root\n")
+	}
+	defer withLocalProbes("/run")()
+	for _, pidfile := range []string{"run/nginx.pid", "/var/run/nginx.pid", "/run/../etc/nginx.pid"} {
+		probe := NewProbe("process", "process://", 5)
+		probe.Options = json.RawMessage(fmt.Sprintf(`{"Pidfile": %q}`, pidfile))
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("pidfile [%s] outside the root should be invalid\n", pidfile)
+		}
+	}
+	if err := runValidators(*NewProbe("process", "process://", 5), urlInvalid); err != nil {
+		t.Errorf("local process probe URL should be valid. got: %v\n", err)
+	}
+}
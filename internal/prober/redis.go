@@ -0,0 +1,225 @@
+package prober
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default timeout of a Redis check, from the connection to the last reply.
+const defaultRedisTimeout = 5 * time.Second
+
+// Limits of the replies read from the server, INFO replication being far below them.
+const (
+	maxRedisBulkSize    = 1 << 20
+	maxRedisArrayLength = 1 << 10
+)
+
+// Roles a Redis server can be asserted to have, INFO replication names replicas "slave".
+var redisRoles = map[string]string{
+	"master":  "master",
+	"replica": "slave",
+	"slave":   "slave",
+}
+
+// redisOptions are the settings of a Redis probe.
+type redisOptions struct {
+	credentialOptions
+	// Replication role the server must have, master or replica. Not checked by default.
+	Role string
+	// Timeout of the check, 5s by default.
+	Timeout duration
+}
+
+// redisChecker authenticates to redis://[user@]host:port URLs, or rediss:// URLs over TLS trusting the CA
+// certificate of the probes, and expects a PONG. The replication role of the server is checked when required.
+type redisChecker struct {
+	address     string
+	username    string
+	credentials credentialOptions
+	role        string
+	tlsConfig   *tls.Config
+	timeout     time.Duration
+}
+
+func newRedisChecker(probe Probe, client *http.Client) (checker, error) {
+	var options redisOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("Redis probe URL must only name a host and a port, e.g. redis://cache.internal:6379")
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, errors.New("password must not be written in the URL, use the PasswordEnv or PasswordFile options")
+	}
+	c := &redisChecker{
+		address:     u.Host,
+		username:    u.User.Username(),
+		credentials: options.credentialOptions,
+		timeout:     options.Timeout.or(defaultRedisTimeout),
+	}
+	if options.Role != "" {
+		var ok bool
+		if c.role, ok = redisRoles[strings.ToLower(options.Role)]; !ok {
+			return nil, fmt.Errorf("Redis role [%s] is unknown, expected master or replica", options.Role)
+		}
+	}
+	if u.Scheme == "rediss" {
+		c.tlsConfig = tlsConfig(client, u.Hostname())
+	}
+	return c, nil
+}
+
+func (c *redisChecker) check() result {
+	password, err := c.credentials.password()
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	start := time.Now()
+	deadline := start.Add(c.timeout)
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	if c.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.address, c.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.address)
+	}
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	defer func() { _ = conn.Close() }()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	reader := bufio.NewReader(conn)
+	command := func(args ...string) (interface{}, error) {
+		if err := writeRedisCommand(conn, args...); err != nil {
+			return nil, err
+		}
+		return readRedisReply(reader)
+	}
+
+	if password != "" {
+		args := []string{"AUTH", password}
+		if c.username != "" {
+			args = []string{"AUTH", c.username, password}
+		}
+		if _, err := command(args...); err != nil {
+			return result{status: DownStatus, err: fmt.Errorf("authentication failed: %w", err)}
+		}
+	}
+	reply, err := command("PING")
+	if err != nil {
+		return result{status: DownStatus, err: fmt.Errorf("PING failed: %w", err)}
+	}
+	if reply != "PONG" {
+		return result{status: DownStatus, err: fmt.Errorf("PING returned [%v] instead of PONG", reply)}
+	}
+	metrics := map[string]float64{"latency_seconds": time.Since(start).Seconds()}
+
+	if c.role != "" {
+		reply, err := command("INFO", "replication")
+		if err != nil {
+			return result{status: DownStatus, err: fmt.Errorf("INFO failed: %w", err), metrics: metrics}
+		}
+		info, _ := reply.(string)
+		role := redisInfoField(info, "role")
+		if role != c.role {
+			return result{status: DownStatus, err: fmt.Errorf("server is %s instead of %s", role, c.role), metrics: metrics}
+		}
+		if replicas, err := strconv.Atoi(redisInfoField(info, "connected_slaves")); err == nil {
+			metrics["connected_replicas"] = float64(replicas)
+		}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
+
+// redisInfoField returns the value of a field of the output of INFO, empty when it is missing.
+func redisInfoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if strings.HasPrefix(line, field+":") {
+			return strings.TrimSpace(strings.TrimPrefix(line, field+":"))
+		}
+	}
+	return ""
+}
+
+// writeRedisCommand sends a command as an array of bulk strings, following the RESP protocol.
+func writeRedisCommand(w io.Writer, args ...string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// readRedisReply reads a RESP reply: strings, integers, nil or arrays of them.
+// Error replies are returned as errors. Lines must fit in the buffer of the reader.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	data, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, fmt.Errorf("Redis reply line is longer than %d bytes", r.Size())
+	}
+	if err != nil {
+		return nil, err
+	}
+	line := strings.TrimSuffix(string(data), "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+	switch payload := line[1:]; line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, errors.New(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		if size > maxRedisBulkSize {
+			return nil, fmt.Errorf("Redis reply of %d bytes is too large", size)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		if count > maxRedisArrayLength {
+			return nil, fmt.Errorf("Redis reply of %d items is too large", count)
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected Redis reply [%s]", line)
+}
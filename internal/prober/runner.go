@@ -1,7 +1,6 @@
 package prober
 
 import (
-	"io/ioutil"
 	"log"
 	"net/http"
 	"time"
@@ -10,11 +9,11 @@ import (
 // runner is an implementation of ProbeRunner
 type runner struct {
 	client   *http.Client
-	alertBus chan<- Probe
+	alertBus chan<- Event
 }
 
 // NewProbeRunner allow to create a new probe runner.
-func NewProbeRunner(httpClient *http.Client, alertBus chan<- Probe) *runner {
+func NewProbeRunner(httpClient *http.Client, alertBus chan<- Event) *runner {
 	return &runner{
 		client:   httpClient,
 		alertBus: alertBus,
@@ -22,32 +21,41 @@ func NewProbeRunner(httpClient *http.Client, alertBus chan<- Probe) *runner {
 }
 
 // run launches probes in a separate goroutine.
+// The probe is checked by the checker of its kind, a probe whose checker can't be built is DOWN.
 func (r *runner) Run(probe *Probe) {
+	var c checker
+	c, err := newChecker(*probe, r.client)
+	if err != nil {
+		c = brokenChecker{err: err}
+	}
 	var oldStatus string
 	for {
 		select {
 		case <-probe.Finish:
-			log.Printf("<<HTTP PROBE [%s]>> Stopping probe...\n", probe.Name)
+			log.Printf("<<PROBE [%s]>> Stopping probe...\n", probe.Name)
 			return
 		default:
-			resp, err := r.client.Get(probe.URL)
 			oldStatus = probe.Status
-			if err != nil {
-				probe.Status = downStatus
-				log.Printf("<<HTTP(s) PROBE [%s]>> Service targeting [%s] is down.\n", probe.Name, probe.URL)
-			} else if resp.StatusCode != 200 {
-				b, _ := ioutil.ReadAll(resp.Body)
-				probe.Status = downStatus
-				log.Printf("<<HTTP(s) PROBE [%s]>> Service targeting [%s] returned an error. got: ['%v']\n", probe.Name, probe.URL, string(b))
+			if probe.Paused() {
+				break
+			}
+			res := c.check()
+			probe.Status = res.status
+			probe.StatusCode = res.statusCode
+			probe.Metrics = res.metrics
+			probe.Message = res.message
+			if res.err != nil {
+				probe.LastError = res.err.Error()
+				log.Printf("<<PROBE [%s]>> Service targeting [%s] is %s. got: ['%v']\n", probe.Name, probe.URL, probe.Status, res.err)
 			} else {
-				probe.Status = upStatus
-				log.Printf("<<HTTP(s) PROBE [%s]>> Service targeting [%s] is alive.\n", probe.Name, probe.URL)
-				_ = resp.Body.Close()
+				log.Printf("<<PROBE [%s]>> Service targeting [%s] is %s.\n", probe.Name, probe.URL, probe.Status)
 			}
 		}
 		// If the status has changed, we can send an event to the alerter bus...
 		if oldStatus != probe.Status {
-			r.alertBus <- *probe
+			event := NewEvent(*probe, oldStatus, time.Now())
+			probe.Since = event.Time
+			r.alertBus <- event
 		}
 		time.Sleep(time.Duration(probe.Delay) * time.Second)
 	}
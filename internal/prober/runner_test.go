@@ -1,5 +1,14 @@
 package prober
 
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
 // mock of the interface ProbeRunner
 type mockRunner struct {
 	RunFn func(probe *Probe)
@@ -15,4 +24,56 @@ func (m *mockRunner) Run(probe *Probe) {
 	m.RunFn(probe)
 }
 
-// TODO: how to test the run function ? :(
+// fakeChecker returns the same result on every check.
+type fakeChecker struct {
+	res result
+}
+
+func (c fakeChecker) check() result {
+	return c.res
+}
+
+// runUntilEvent runs the probe until its first status change and returns the event.
+func runUntilEvent(t *testing.T, probe *Probe) Event {
+	alertBus := make(chan Event, 1)
+	go NewProbeRunner(nil, alertBus).Run(probe)
+	defer func() { probe.Finish <- true }()
+	select {
+	case event := <-alertBus:
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("probe [%s] should change status\n", probe.Name)
+	}
+	return Event{}
+}
+
+func TestRunReportsCheckResult(t *testing.T) {
+	res := result{
+		status:     DegradedStatus,
+		statusCode: 200,
+		err:        errors.New("answered in 2s"),
+		metrics:    map[string]float64{"latency_seconds": 2},
+		message:    "slow answer",
+	}
+	kinds["fake"] = kind{newChecker: func(Probe, *http.Client) (checker, error) { return fakeChecker{res: res}, nil }, hostless: true}
+	defer delete(kinds, "fake")
+
+	event := runUntilEvent(t, NewProbe("fake", "fake://", 0))
+	probe := event.Probe
+	if probe.Status != DegradedStatus || probe.StatusCode != 200 || probe.LastError != "answered in 2s" || probe.Message != "slow answer" {
+		t.Errorf("probe should be updated from the check result. got: %+v\n", probe)
+	}
+	if !reflect.DeepEqual(probe.Metrics, res.metrics) {
+		t.Errorf("probe should expose the metrics of the check. got: %v\n", probe.Metrics)
+	}
+	if event.PreviousStatus != "" || probe.Since.IsZero() {
+		t.Errorf("first check should be reported as a status change. got: %+v\n", event)
+	}
+}
+
+func TestRunReportsBrokenProbeDown(t *testing.T) {
+	event := runUntilEvent(t, NewProbe("broken", "gopher://localhost/", 0))
+	if event.Probe.Status != DownStatus || !strings.Contains(event.Probe.LastError, "scheme [gopher] is not supported") {
+		t.Errorf("probe whose checker can't be built should be DOWN with the reason. got: %s %s\n", event.Probe.Status, event.Probe.LastError)
+	}
+}
@@ -6,10 +6,18 @@ import (
 	"errors"
 	"github.com/madjlzz/madprobe/internal/persistence"
 	"log"
+	"sync"
 )
 
-const downStatus = "DOWN"
-const upStatus = "UP"
+// Statuses a probe can report.
+const DownStatus = "DOWN"
+const UpStatus = "UP"
+
+// DegradedStatus is reported by the probes still answering but beyond their thresholds.
+const DegradedStatus = "DEGRADED"
+
+// UnknownStatus is reported by the probes whose target can't tell its health.
+const UnknownStatus = "UNKNOWN"
 
 var (
 	ErrProbeAlreadyExist = errors.New("probe with this name already exists")
@@ -22,7 +30,9 @@ var instance *service
 type service struct {
 	runner    ProbeRunner
 	persister persistence.Persister
-	probes    map[string]*Probe
+	// Probes are managed concurrently by the API and the Discord commands.
+	mu     sync.RWMutex
+	probes map[string]*Probe
 }
 
 // NewProbeService allow to create a new probe service.
@@ -44,11 +54,13 @@ func NewProbeService(runner ProbeRunner, persister persistence.Persister) *servi
 // Validation is made before storing the probe to be sure nothing partially configured enters the system.
 // Local cache is also updated.
 func (ps *service) Insert(probe Probe) error {
-	err := runValidators(probe, nameInvalid, urlInvalid, delayInvalid)
+	err := runValidators(probe, nameInvalid, urlInvalid, kindInvalid, delayInvalid, labelsInvalid, severityInvalid)
 	if err != nil {
 		return err
 	}
 
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 	entity, err := ps.persister.Get(probe.Name)
 	if err != nil {
 		return err
@@ -58,11 +70,19 @@ func (ps *service) Insert(probe Probe) error {
 	}
 
 	entity = persistence.NewEntity(probe.Name, probe.URL, probe.Delay)
+	entity.Labels = probe.Labels
+	entity.Options = probe.Options
+	entity.Severity = probe.Severity
+	entity.Paused = probe.Paused()
 	err = ps.persister.Insert(entity)
 	if err != nil {
 		return err
 	}
 
+	// Probes built without NewProbe have no pause state yet.
+	if probe.paused == nil {
+		probe.paused = new(int32)
+	}
 	ps.probes[probe.Name] = &probe
 	go ps.runner.Run(&probe)
 
@@ -77,7 +97,10 @@ func (ps *service) Get(name string) (*Probe, error) {
 	if err != nil {
 		return nil, err
 	}
-	if probe == nil {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	// BoltDB returns an empty entity when the probe doesn't exist.
+	if probe == nil || probe.Name == "" || ps.probes[name] == nil {
 		return nil, ErrProbeNotFound
 	}
 	return ps.probes[name], nil
@@ -89,6 +112,8 @@ func (ps *service) GetAll() ([]*Probe, error) {
 	if err != nil {
 		return nil, err
 	}
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
 	var probes []*Probe
 	for _, entity := range entities {
 		probes = append(probes, ps.probes[entity.Name])
@@ -106,13 +131,48 @@ func (ps *service) Delete(name string) error {
 		return err
 	}
 
+	ps.mu.Lock()
 	err = ps.persister.Delete(name)
 	if err != nil {
+		ps.mu.Unlock()
 		return err
 	}
-	ps.probes[name].Finish <- true
+	deleted := ps.probes[name]
 	delete(ps.probes, name)
+	ps.mu.Unlock()
+	// The runner only stops between two checks, the other probes must not wait for it.
+	deleted.Finish <- true
+
+	return nil
+}
+
+// Pause stops checking an existing probe until it is resumed.
+// The pause is persisted so that it survives restarts.
+func (ps *service) Pause(name string) error {
+	return ps.setPaused(name, true)
+}
+
+// Resume checks a paused probe again.
+func (ps *service) Resume(name string) error {
+	return ps.setPaused(name, false)
+}
 
+func (ps *service) setPaused(name string, paused bool) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	entity, err := ps.persister.Get(name)
+	if err != nil {
+		return err
+	}
+	if entity == nil || entity.Name == "" || ps.probes[name] == nil {
+		return ErrProbeNotFound
+	}
+	entity.Paused = paused
+	err = ps.persister.Insert(entity)
+	if err != nil {
+		return err
+	}
+	ps.probes[name].SetPaused(paused)
 	return nil
 }
 
@@ -123,6 +183,10 @@ func (ps *service) runProbes() error {
 	}
 	for _, entity := range entities {
 		probe := NewProbe(entity.Name, entity.URL, entity.Delay)
+		probe.Labels = entity.Labels
+		probe.Options = entity.Options
+		probe.Severity = entity.Severity
+		probe.SetPaused(entity.Paused)
 		ps.probes[entity.Name] = probe
 		go ps.runner.Run(probe)
 	}
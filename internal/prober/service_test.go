@@ -6,6 +6,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/madjlzz/madprobe/internal/mock"
 	"github.com/madjlzz/madprobe/internal/persistence"
+	"sync"
 	"testing"
 )
 
@@ -312,3 +313,137 @@ func TestDeleteReturnErrorOnDeleteFailure(t *testing.T) {
 	}
 
 }*/
+
+func TestPauseReturnErrProbeNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockPersister(ctrl)
+	m.EXPECT().GetAll().Times(1)
+
+	m.EXPECT().
+		Get(gomock.Any()).
+		Return(&persistence.Entity{}, nil).
+		Times(1)
+
+	s := NewProbeService(nil, m)
+
+	err := s.Pause("TheName")
+	if !errors.Is(err, ErrProbeNotFound) {
+		t.Error("returned error should be [ErrProbeNotFound]")
+	}
+}
+
+func TestPauseAndResumePersistState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entity := persistence.NewEntity("TheName", "http://localhost/", 5)
+
+	m := mock.NewMockPersister(ctrl)
+	m.EXPECT().GetAll().Times(1)
+
+	m.EXPECT().
+		Get(gomock.Any()).
+		Return(entity, nil).
+		Times(2)
+	pause := m.EXPECT().
+		Insert(&persistence.Entity{Name: "TheName", URL: "http://localhost/", Delay: 5, Paused: true}).
+		Return(nil)
+	resume := m.EXPECT().
+		Insert(&persistence.Entity{Name: "TheName", URL: "http://localhost/", Delay: 5}).
+		Return(nil)
+	gomock.InOrder(pause, resume)
+
+	s := NewProbeService(nil, m)
+	probe := NewProbe(entity.Name, entity.URL, entity.Delay)
+	s.probes[entity.Name] = probe
+
+	if err := s.Pause("TheName"); err != nil {
+		t.Fatalf("no error should have been registered. got: %v\n", err)
+	}
+	if !probe.Paused() {
+		t.Error("cached probe should be paused")
+	}
+	if err := s.Resume("TheName"); err != nil {
+		t.Fatalf("no error should have been registered. got: %v\n", err)
+	}
+	if probe.Paused() {
+		t.Error("cached probe should be resumed")
+	}
+}
+
+func TestPauseRunningProbe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entity := persistence.NewEntity("TheName", "unknown://localhost/", 0)
+
+	m := mock.NewMockPersister(ctrl)
+	m.EXPECT().GetAll().Times(1)
+	m.EXPECT().Get(gomock.Any()).Return(entity, nil).AnyTimes()
+	m.EXPECT().Insert(gomock.Any()).Return(nil).AnyTimes()
+
+	alertBus := make(chan Event, 1)
+	runner := NewProbeRunner(nil, alertBus)
+	s := NewProbeService(runner, m)
+	probe := NewProbe(entity.Name, entity.URL, entity.Delay)
+	s.probes[entity.Name] = probe
+	go runner.Run(probe)
+	defer func() { probe.Finish <- true }()
+
+	if event := <-alertBus; event.Probe.Status != DownStatus {
+		t.Fatalf("probe should be checked. got: %s\n", event.Probe.Status)
+	}
+	for i := 0; i < 10; i++ {
+		if err := s.Pause("TheName"); err != nil || !probe.Paused() {
+			t.Fatalf("running probe should be paused. got: %v\n", err)
+		}
+		if err := s.Resume("TheName"); err != nil || probe.Paused() {
+			t.Fatalf("running probe should be resumed. got: %v\n", err)
+		}
+	}
+}
+
+func TestInsertAndPauseConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockPersister(ctrl)
+	m.EXPECT().GetAll().Times(1)
+	m.EXPECT().
+		Get(gomock.Any()).
+		DoAndReturn(func(name string) (*persistence.Entity, error) {
+			if name == "TheName" {
+				return persistence.NewEntity(name, "http://localhost/", 5), nil
+			}
+			return &persistence.Entity{}, nil
+		}).
+		AnyTimes()
+	m.EXPECT().Insert(gomock.Any()).Return(nil).AnyTimes()
+
+	s := NewProbeService(NewMockRunner(func(*Probe) {}), m)
+	s.probes["TheName"] = NewProbe("TheName", "http://localhost/", 5)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.Insert(*NewProbe(fmt.Sprintf("probe-%d", i), "http://localhost/", 5)); err != nil {
+				t.Errorf("probe should be inserted. got: %v\n", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if err := s.Pause("TheName"); err != nil {
+				t.Errorf("probe should be paused. got: %v\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(s.probes) != 11 {
+		t.Errorf("every probe should be cached. got: %d\n", len(s.probes))
+	}
+}
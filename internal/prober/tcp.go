@@ -0,0 +1,225 @@
+package prober
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Default timeout of a TCP script, from the connection to the last step.
+const defaultTCPTimeout = 10 * time.Second
+
+// Largest data a TCP step reads while waiting for its expected response.
+const maxExpectSize = 64 * 1024
+
+// Largest data quoted in the errors of the probes reading responses.
+const maxQuotedSize = 256
+
+// tcpStep is a step of the script of a TCP probe, its actions are done in order: Send, Expect then StartTLS.
+type tcpStep struct {
+	// Data written to the connection, e.g. "PING\r\n".
+	Send string
+	// Regular expression the data read must match, reading continues until it does or the timeout expires.
+	// The rest of the matched line is skipped, the next step expects the following lines.
+	Expect string
+	// Upgrades the connection to TLS, once the server accepted it.
+	StartTLS bool
+}
+
+// tcpOptions are the settings of a TCP probe.
+type tcpOptions struct {
+	// Script of one of the supported protocols: smtp, pop3, imap, redis or mysql.
+	Preset string
+	// Upgrades the connection of the smtp, pop3 and imap presets to TLS.
+	StartTLS bool
+	// Script run when no preset is given, the probe only connects without steps.
+	Steps []tcpStep
+	// Timeout of the whole script, 10s by default.
+	Timeout duration
+}
+
+// Scripts of the presets, by name. Each one is given whether STARTTLS is required.
+var tcpPresets = map[string]func(startTLS bool) ([]tcpStep, error){
+	"smtp": func(startTLS bool) ([]tcpStep, error) {
+		steps := []tcpStep{{Expect: `^220[ -]`}, {Send: "EHLO madprobe\r\n", Expect: `(?m)^250 `}}
+		if startTLS {
+			steps = append(steps,
+				tcpStep{Send: "STARTTLS\r\n", Expect: `^220 `, StartTLS: true},
+				tcpStep{Send: "EHLO madprobe\r\n", Expect: `(?m)^250 `})
+		}
+		return append(steps, tcpStep{Send: "QUIT\r\n"}), nil
+	},
+	"pop3": func(startTLS bool) ([]tcpStep, error) {
+		steps := []tcpStep{{Expect: `^\+OK`}}
+		if startTLS {
+			steps = append(steps, tcpStep{Send: "STLS\r\n", Expect: `^\+OK`, StartTLS: true})
+		}
+		return append(steps, tcpStep{Send: "QUIT\r\n", Expect: `^\+OK`}), nil
+	},
+	"imap": func(startTLS bool) ([]tcpStep, error) {
+		steps := []tcpStep{{Expect: `^\* OK`}}
+		if startTLS {
+			steps = append(steps, tcpStep{Send: "a1 STARTTLS\r\n", Expect: `(?m)^a1 OK`, StartTLS: true})
+		}
+		return append(steps, tcpStep{Send: "a2 LOGOUT\r\n", Expect: `(?m)^a2 OK`}), nil
+	},
+	"redis": func(startTLS bool) ([]tcpStep, error) {
+		if startTLS {
+			return nil, errors.New("redis has no STARTTLS, use a tls:// URL")
+		}
+		return []tcpStep{{Send: "PING\r\n", Expect: `^\+PONG\r\n`}}, nil
+	},
+	"mysql": func(startTLS bool) ([]tcpStep, error) {
+		if startTLS {
+			return nil, errors.New("mysql STARTTLS is not supported")
+		}
+		// The initial handshake packet has the sequence 0, the protocol version 10 and the server version,
+		// servers refusing the client send an error packet instead.
+		return []tcpStep{{Expect: `(?s)^.{3}\x00\x0a[0-9]+\.[0-9]+`}}, nil
+	},
+}
+
+// tcpChecker runs a send/expect script against tcp://host:port URLs, or tls://host:port URLs
+// whose connection is TLS from the start. TLS trusts the CA certificate of the probes.
+type tcpChecker struct {
+	address   string
+	steps     []tcpStep
+	expects   []*regexp.Regexp
+	tls       bool
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+func newTCPChecker(probe Probe, client *http.Client) (checker, error) {
+	var options tcpOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("TCP probe URL must only name a host and a port, e.g. tcp://mail.example.com:25")
+	}
+	c := &tcpChecker{
+		address:   u.Host,
+		steps:     options.Steps,
+		tls:       u.Scheme == "tls",
+		tlsConfig: tlsConfig(client, u.Hostname()),
+		timeout:   options.Timeout.or(defaultTCPTimeout),
+	}
+
+	if options.Preset != "" {
+		preset, ok := tcpPresets[strings.ToLower(options.Preset)]
+		if !ok {
+			return nil, fmt.Errorf("preset [%s] is unknown", options.Preset)
+		}
+		if len(options.Steps) > 0 {
+			return nil, errors.New("steps can't be given with a preset")
+		}
+		if c.steps, err = preset(options.StartTLS); err != nil {
+			return nil, err
+		}
+	} else if options.StartTLS {
+		return nil, errors.New("STARTTLS option requires a preset, use a step upgrading to TLS instead")
+	}
+
+	upgraded := c.tls
+	for i, step := range c.steps {
+		if step.Send == "" && step.Expect == "" && !step.StartTLS {
+			return nil, fmt.Errorf("step %d does nothing", i+1)
+		}
+		if step.StartTLS && upgraded {
+			return nil, fmt.Errorf("step %d upgrades a connection already using TLS", i+1)
+		}
+		upgraded = upgraded || step.StartTLS
+		var expect *regexp.Regexp
+		if step.Expect != "" {
+			if expect, err = regexp.Compile(step.Expect); err != nil {
+				return nil, fmt.Errorf("expected response of step %d is not a valid regular expression: %w", i+1, err)
+			}
+		}
+		c.expects = append(c.expects, expect)
+	}
+	return c, nil
+}
+
+func (c *tcpChecker) check() result {
+	start := time.Now()
+	deadline := start.Add(c.timeout)
+	dialer := &net.Dialer{Deadline: deadline}
+	var conn net.Conn
+	var err error
+	if c.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.address, c.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.address)
+	}
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	defer func() { _ = conn.Close() }()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return result{status: DownStatus, err: err}
+	}
+
+	var buf []byte
+	for i, step := range c.steps {
+		if step.Send != "" {
+			if _, err := conn.Write([]byte(step.Send)); err != nil {
+				return result{status: DownStatus, err: fmt.Errorf("step %d could not send: %w", i+1, err)}
+			}
+		}
+		if expect := c.expects[i]; expect != nil {
+			if buf, err = expectResponse(conn, buf, expect); err != nil {
+				return result{status: DownStatus, err: fmt.Errorf("step %d: %w", i+1, err)}
+			}
+		}
+		if step.StartTLS {
+			tlsConn := tls.Client(conn, c.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return result{status: DownStatus, err: fmt.Errorf("step %d could not start TLS: %w", i+1, err)}
+			}
+			conn, buf = tlsConn, nil
+		}
+	}
+	return result{status: UpStatus, metrics: map[string]float64{"latency_seconds": time.Since(start).Seconds()}}
+}
+
+// expectResponse reads from the connection until the data matches, and returns the data left after the line of the match.
+func expectResponse(conn net.Conn, buf []byte, expect *regexp.Regexp) ([]byte, error) {
+	chunk := make([]byte, 4096)
+	for {
+		if loc := expect.FindIndex(buf); loc != nil {
+			rest := buf[loc[1]:]
+			if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+				return rest[i+1:], nil
+			}
+			return nil, nil
+		}
+		if len(buf) >= maxExpectSize {
+			return nil, fmt.Errorf("expected [%s], got %q", expect, quoteLimit(buf, maxQuotedSize))
+		}
+		n, err := conn.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if err != nil && !expect.Match(buf) {
+			return nil, fmt.Errorf("expected [%s], got %q: %v", expect, quoteLimit(buf, maxQuotedSize), err)
+		}
+	}
+}
+
+// quoteLimit shortens the data quoted in the errors to max bytes.
+func quoteLimit(data []byte, max int) []byte {
+	if len(data) > max {
+		return data[:max]
+	}
+	return data
+}
@@ -0,0 +1,170 @@
+package prober
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeLineServer greets its clients then answers their lines, the lines starting a STARTTLS upgrade it.
+type fakeLineServer struct {
+	tlsConfig *tls.Config
+	greeting  string
+	answers   map[string]string
+	startTLS  string
+}
+
+// newTLSTestConfig returns a certificate valid for 127.0.0.1 and the client trusting it, generated by httptest.
+func newTLSTestConfig() (*tls.Config, *http.Client) {
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	defer https.Close()
+	return &tls.Config{Certificates: https.TLS.Certificates}, https.Client()
+}
+
+func startFakeLineServer(t *testing.T, s *fakeLineServer, implicitTLS bool) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fake server should listen. got: %v\n", err)
+	}
+	if implicitTLS {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return listener.Addr().String(), func() { _ = listener.Close() }
+}
+
+func (s *fakeLineServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	_, _ = conn.Write([]byte(s.greeting))
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		_, _ = conn.Write([]byte(s.answers[line]))
+		if line == s.startTLS {
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, reader = tlsConn, bufio.NewReader(tlsConn)
+		}
+	}
+}
+
+func newTestTCPChecker(t *testing.T, url string, options string, client *http.Client) checker {
+	probe := NewProbe("tcp", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, client)
+	if err != nil {
+		t.Fatalf("TCP checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestTCPCheckerRunsPresets(t *testing.T) {
+	tlsConfig, client := newTLSTestConfig()
+	smtp, stopSMTP := startFakeLineServer(t, &fakeLineServer{
+		tlsConfig: tlsConfig,
+		greeting:  "220 mail.example.com ESMTP\r\n",
+		answers: map[string]string{
+			"EHLO madprobe": "250-mail.example.com\r\n250-STARTTLS\r\n250 SMTPUTF8\r\n",
+			"STARTTLS":      "220 Ready to start TLS\r\n",
+			"QUIT":          "221 Bye\r\n",
+		},
+		startTLS: "STARTTLS",
+	}, false)
+	defer stopSMTP()
+	imap, stopIMAP := startFakeLineServer(t, &fakeLineServer{
+		tlsConfig: tlsConfig,
+		greeting:  "* OK IMAP4rev1 ready\r\n",
+		answers:   map[string]string{"a2 LOGOUT": "* BYE\r\na2 OK LOGOUT completed\r\n"},
+	}, true)
+	defer stopIMAP()
+	redis, stopRedis := startFakeLineServer(t, &fakeLineServer{answers: map[string]string{"PING": "+PONG\r\n"}}, false)
+	defer stopRedis()
+	mysql, stopMySQL := startFakeLineServer(t, &fakeLineServer{greeting: "\x4a\x00\x00\x00\x0a8.0.26\x00\x08\x00\x00\x00"}, false)
+	defer stopMySQL()
+
+	up := []struct{ url, options string }{
+		{"tcp://" + smtp, `{"Preset": "smtp"}`},
+		{"tcp://" + smtp, `{"Preset": "SMTP", "StartTLS": true}`},
+		{"tls://" + imap, `{"Preset": "imap"}`},
+		{"tcp://" + redis, `{"Preset": "redis"}`},
+		{"tcp://" + mysql, `{"Preset": "mysql"}`},
+		{"tcp://" + redis, ""},
+		{"tcp://" + smtp, `{"Steps": [{"Expect": "^220 "}, {"Send": "EHLO madprobe\r\n", "Expect": "STARTTLS"}, {"Expect": "^250 SMTPUTF8"}]}`},
+	}
+	for _, probe := range up {
+		res := newTestTCPChecker(t, probe.url, probe.options, client).check()
+		if res.status != UpStatus {
+			t.Errorf("probe [%s] with options [%s] should be UP. got: %s %v\n", probe.url, probe.options, res.status, res.err)
+		}
+		if _, ok := res.metrics["latency_seconds"]; !ok {
+			t.Errorf("probe [%s] should measure the latency. got: %v\n", probe.url, res.metrics)
+		}
+	}
+
+	down := []struct{ url, options string }{
+		{"tcp://" + smtp, `{"Preset": "pop3", "Timeout": "200ms"}`},
+		{"tcp://" + smtp, `{"Preset": "smtp", "StartTLS": true}`},
+		{"tcp://" + redis, `{"Steps": [{"Send": "PING\r\n", "Expect": "^\\+PONG v2"}], "Timeout": "200ms"}`},
+		{"tcp://" + imap, `{"Preset": "imap", "Timeout": "200ms"}`},
+	}
+	for i, probe := range down {
+		// The second probe doesn't trust the CA certificate of the server.
+		c := client
+		if i == 1 {
+			c = http.DefaultClient
+		}
+		if res := newTestTCPChecker(t, probe.url, probe.options, c).check(); res.status != DownStatus || res.err == nil {
+			t.Errorf("probe [%s] with options [%s] should be DOWN with a reason. got: %s\n", probe.url, probe.options, res.status)
+		}
+	}
+
+	stopRedis()
+	if res := newTestTCPChecker(t, "tcp://"+redis, "", client).check(); res.status != DownStatus {
+		t.Errorf("probe should be DOWN when the port is closed. got: %s\n", res.status)
+	}
+}
+
+func TestTCPCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"tcp://127.0.0.1":        "",
+		"tcp://127.0.0.1:25/foo": "",
+		"tcp://127.0.0.1:25":     `{"Preset": "ftp"}`,
+		"tcp://127.0.0.1:6379":   `{"Preset": "redis", "StartTLS": true}`,
+		"tcp://127.0.0.1:110":    `{"StartTLS": true}`,
+		"tcp://127.0.0.1:143":    `{"Preset": "imap", "Steps": [{"Send": "a1 NOOP\r\n"}]}`,
+		"tcp://127.0.0.1:1":      `{"Steps": [{}]}`,
+		"tcp://127.0.0.1:2":      `{"Steps": [{"Expect": "("}]}`,
+		"tls://127.0.0.1:993":    `{"Preset": "imap", "StartTLS": true}`,
+		"tcp://localhost:3":      `{"Steps": [{"StartTLS": true}, {"StartTLS": true}]}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("tcp", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+}
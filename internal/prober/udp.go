@@ -0,0 +1,121 @@
+package prober
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Default timeout of a UDP exchange.
+const defaultUDPTimeout = 2 * time.Second
+
+// Largest datagram a UDP probe reads.
+const maxDatagramSize = 65535
+
+// udpOptions are the settings of a UDP probe.
+type udpOptions struct {
+	// Datagram sent on every check, as text or as hexadecimal bytes.
+	Payload    string
+	PayloadHex string
+	// The response must match the regular expression, or start with the hexadecimal bytes.
+	Expect    string
+	ExpectHex string
+	// How long to wait for the response, 2s by default.
+	Timeout duration
+}
+
+// udpChecker sends a datagram to udp://host:port URLs and checks the response.
+// Without expectation the probe only fails when the port is reported unreachable during the timeout,
+// since UDP services don't have to answer.
+type udpChecker struct {
+	address string
+	payload []byte
+	expect  *regexp.Regexp
+	prefix  []byte
+	timeout time.Duration
+}
+
+func newUDPChecker(probe Probe, _ *http.Client) (checker, error) {
+	var options udpOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Port() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, errors.New("UDP probe URL must only name a host and a port, e.g. udp://192.0.2.1:514")
+	}
+	c := &udpChecker{address: u.Host, timeout: options.Timeout.or(defaultUDPTimeout)}
+
+	if options.Payload != "" && options.PayloadHex != "" {
+		return nil, errors.New("payload must be given either as text or as hexadecimal")
+	}
+	c.payload = []byte(options.Payload)
+	if options.PayloadHex != "" {
+		if c.payload, err = hex.DecodeString(options.PayloadHex); err != nil {
+			return nil, fmt.Errorf("payload is not hexadecimal: %w", err)
+		}
+	}
+
+	if options.Expect != "" && options.ExpectHex != "" {
+		return nil, errors.New("response must be expected either by a regular expression or by hexadecimal bytes")
+	}
+	if options.Expect != "" {
+		if c.expect, err = regexp.Compile(options.Expect); err != nil {
+			return nil, fmt.Errorf("expected response is not a valid regular expression: %w", err)
+		}
+	}
+	if options.ExpectHex != "" {
+		if c.prefix, err = hex.DecodeString(options.ExpectHex); err != nil {
+			return nil, fmt.Errorf("expected response is not hexadecimal: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *udpChecker) check() result {
+	// A connected socket receives the ICMP port unreachable errors as connection refused.
+	conn, err := net.DialTimeout("udp", c.address, c.timeout)
+	if err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if err := conn.SetDeadline(start.Add(c.timeout)); err != nil {
+		return result{status: DownStatus, err: err}
+	}
+	if _, err := conn.Write(c.payload); err != nil {
+		return result{status: DownStatus, err: fmt.Errorf("could not send datagram: %w", err)}
+	}
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && c.expect == nil && c.prefix == nil {
+			return result{status: UpStatus}
+		}
+		return result{status: DownStatus, err: fmt.Errorf("no response from [%s]: %w", c.address, err)}
+	}
+
+	metrics := map[string]float64{
+		"latency_seconds":     time.Since(start).Seconds(),
+		"response_size_bytes": float64(n),
+	}
+	response := buf[:n]
+	if c.expect != nil && !c.expect.Match(response) {
+		return result{status: DownStatus, err: fmt.Errorf("response %q doesn't match [%s]", quoteLimit(response, maxQuotedSize), c.expect), metrics: metrics}
+	}
+	if c.prefix != nil && !bytes.HasPrefix(response, c.prefix) {
+		return result{status: DownStatus, err: fmt.Errorf("response [%x] doesn't start with [%x]", quoteLimit(response, maxQuotedSize), c.prefix), metrics: metrics}
+	}
+	return result{status: UpStatus, metrics: metrics}
+}
@@ -0,0 +1,114 @@
+package prober
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+// startUDPServer answers every datagram with the given function on a random local port and returns its address.
+func startUDPServer(t *testing.T, answer func(request []byte) []byte) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("UDP server should listen. got: %v\n", err)
+	}
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if response := answer(buf[:n]); response != nil {
+				_, _ = conn.WriteTo(response, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String(), func() { _ = conn.Close() }
+}
+
+func newTestUDPChecker(t *testing.T, url string, options string) checker {
+	probe := NewProbe("udp", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, nil)
+	if err != nil {
+		t.Fatalf("UDP checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestUDPCheckerExpectsResponse(t *testing.T) {
+	addr, stop := startUDPServer(t, func(request []byte) []byte {
+		switch {
+		case bytes.Equal(request, []byte("ping")):
+			return []byte("pong v1.2")
+		case bytes.Equal(request, []byte{0xff, 0xff, 0xff, 0xff}):
+			return []byte{0xff, 0xff, 0xff, 0xff, 0x49, 0x11}
+		case bytes.Equal(request, []byte("large")):
+			return bytes.Repeat([]byte("a"), 10000)
+		}
+		return nil
+	})
+	defer stop()
+
+	up := []string{
+		`{"Payload": "ping", "Expect": "^pong v\\d"}`,
+		`{"PayloadHex": "ffffffff", "ExpectHex": "FFFFFFFF49"}`,
+		`{"Payload": "ping"}`,
+		`{"Payload": "ignored", "Timeout": "100ms"}`,
+	}
+	for _, options := range up {
+		if res := newTestUDPChecker(t, "udp://"+addr, options).check(); res.status != UpStatus {
+			t.Errorf("probe with options [%s] should be UP. got: %s %v\n", options, res.status, res.err)
+		}
+	}
+	res := newTestUDPChecker(t, "udp://"+addr, `{"Payload": "ping"}`).check()
+	if res.metrics["response_size_bytes"] != 9 {
+		t.Errorf("probe should measure the response. got: %v\n", res.metrics)
+	}
+
+	down := []string{
+		`{"Payload": "ping", "Expect": "^pong v2"}`,
+		`{"PayloadHex": "ffffffff", "ExpectHex": "ffffffff54"}`,
+		`{"Payload": "ignored", "Expect": "pong", "Timeout": "100ms"}`,
+	}
+	for _, options := range down {
+		if res := newTestUDPChecker(t, "udp://"+addr, options).check(); res.status != DownStatus || res.err == nil {
+			t.Errorf("probe with options [%s] should be DOWN with a reason. got: %s\n", options, res.status)
+		}
+	}
+	for _, options := range []string{`{"Payload": "large", "Expect": "^pong"}`, `{"Payload": "large", "ExpectHex": "ff"}`} {
+		res := newTestUDPChecker(t, "udp://"+addr, options).check()
+		if res.err == nil || len(res.err.Error()) > 2*maxQuotedSize+64 {
+			t.Errorf("probe with options [%s] should quote a part of the response only. got: %v\n", options, res.err)
+		}
+	}
+
+	stop()
+	if res := newTestUDPChecker(t, "udp://"+addr, `{"Payload": "ping", "Timeout": "500ms"}`).check(); res.status != DownStatus {
+		t.Errorf("probe should be DOWN when the port is unreachable. got: %s\n", res.status)
+	}
+}
+
+func TestUDPCheckerValidation(t *testing.T) {
+	invalid := map[string]string{
+		"udp://127.0.0.1":         "",
+		"udp://127.0.0.1:514/foo": "",
+		"udp://127.0.0.1:514":     `{"PayloadHex": "zz"}`,
+		"udp://127.0.0.1:515":     `{"Payload": "a", "PayloadHex": "61"}`,
+		"udp://127.0.0.1:516":     `{"Expect": "("}`,
+		"udp://127.0.0.1:517":     `{"Expect": "a", "ExpectHex": "61"}`,
+	}
+	for url, options := range invalid {
+		probe := NewProbe("udp", url, 5)
+		if options != "" {
+			probe.Options = json.RawMessage(options)
+		}
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe [%s] with options [%s] should be invalid\n", url, options)
+		}
+	}
+}
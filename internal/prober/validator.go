@@ -2,6 +2,7 @@ package prober
 
 import (
 	"fmt"
+	"github.com/madjlzz/madprobe/internal/labels"
 	"net/url"
 )
 
@@ -44,7 +45,7 @@ func urlInvalid(probe Probe) error {
 		}
 	}
 	u, err := url.Parse(probe.URL)
-	if err != nil || u.Scheme == "" || u.Host == "" {
+	if err != nil || u.Scheme == "" || (u.Host == "" && !kinds[u.Scheme].hostless) {
 		return &validatorError{
 			field: "URL",
 			msg:   "URL is malformed",
@@ -53,6 +54,19 @@ func urlInvalid(probe Probe) error {
 	return nil
 }
 
+// Validate the kind of the probe.
+// Returns an error if the scheme of the URL is not supported or if the URL and the options
+// don't suit the kind.
+func kindInvalid(probe Probe) error {
+	if _, err := newChecker(probe, nil); err != nil {
+		return &validatorError{
+			field: "Options",
+			msg:   err.Error(),
+		}
+	}
+	return nil
+}
+
 // Validate the delay property of the probe.
 // Returns an error if the delay is 0 or negative.
 func delayInvalid(probe Probe) error {
@@ -65,6 +79,39 @@ func delayInvalid(probe Probe) error {
 	return nil
 }
 
+// Validate the labels of the probe.
+// Returns an error if a key or a value could not be used in a selector.
+func labelsInvalid(probe Probe) error {
+	if err := labels.Validate(probe.Labels); err != nil {
+		return &validatorError{
+			field: "Labels",
+			msg:   err.Error(),
+		}
+	}
+	return nil
+}
+
+// Validate the severity of the probe.
+// Returns an error if it is set to an unknown severity.
+func severityInvalid(probe Probe) error {
+	if !ValidSeverity(probe.Severity) {
+		return &validatorError{
+			field: "Severity",
+			msg:   fmt.Sprintf("Severity must be one of [%s, %s, %s]", CriticalSeverity, WarningSeverity, InfoSeverity),
+		}
+	}
+	return nil
+}
+
+// ValidSeverity tells if the given severity is empty or known.
+func ValidSeverity(severity string) bool {
+	switch severity {
+	case "", CriticalSeverity, WarningSeverity, InfoSeverity:
+		return true
+	}
+	return false
+}
+
 // Handy type that allow us to pass a function that takes a probe
 // for validation.
 type validateFunc func(probe Probe) error
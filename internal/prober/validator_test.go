@@ -126,3 +126,66 @@ func TestRunValidatorWithNoError(t *testing.T) {
 		t.Errorf("no error should be thrown with a valid probe.")
 	}
 }
+
+func TestLabelsInvalid(t *testing.T) {
+	probe := NewProbe("ValidName", "http://localhost/", 1)
+	probe.Labels = map[string]string{"team": "pay,ments"}
+	err := labelsInvalid(*probe)
+	if err == nil {
+		t.Errorf("the probe's labels are invalid. an error should be returned.")
+	}
+	if e, ok := err.(*validatorError); ok {
+		if e.field != "Labels" {
+			t.Errorf("validatorError field must be [Labels]. got: %s\n", e.field)
+		}
+	}
+}
+
+func TestLabelsValid(t *testing.T) {
+	probe := NewProbe("ValidName", "http://localhost/", 1)
+	probe.Labels = map[string]string{"team": "payments", "env": "prod"}
+	err := labelsInvalid(*probe)
+	if err != nil {
+		t.Errorf("no error should be thrown with valid probe's labels.")
+	}
+}
+
+func TestSeverityInvalid(t *testing.T) {
+	probe := NewProbe("ValidName", "http://localhost/", 1)
+	probe.Severity = "urgent"
+	err := severityInvalid(*probe)
+	if err == nil {
+		t.Errorf("the probe's severity is invalid. an error should be returned.")
+	}
+	if e, ok := err.(*validatorError); ok {
+		if e.field != "Severity" {
+			t.Errorf("validatorError field must be [Severity]. got: %s\n", e.field)
+		}
+	}
+}
+
+func TestSeverityValid(t *testing.T) {
+	probe := NewProbe("ValidName", "http://localhost/", 1)
+	for _, severity := range []string{"", CriticalSeverity, WarningSeverity, InfoSeverity} {
+		probe.Severity = severity
+		if err := severityInvalid(*probe); err != nil {
+			t.Errorf("no error should be thrown with severity [%s].", severity)
+		}
+	}
+}
+
+func TestKindInvalid(t *testing.T) {
+	probe := NewProbe("", "gopher://localhost/", 0)
+	if err := kindInvalid(*probe); err == nil {
+		t.Errorf("probes with an unsupported scheme should be invalid.")
+	}
+	probe = NewProbe("", "http://localhost/", 0)
+	probe.Options = []byte(`{"Unknown": true}`)
+	if err := kindInvalid(*probe); err == nil {
+		t.Errorf("unknown options should be invalid.")
+	}
+	probe.Options = nil
+	if err := kindInvalid(*probe); err != nil {
+		t.Errorf("no error should be thrown with a valid HTTP probe. got: %v\n", err)
+	}
+}
@@ -0,0 +1,103 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Default timeout of a WebSocket check, from the handshake to the reply.
+const defaultWebSocketTimeout = 5 * time.Second
+
+// webSocketOptions are the settings of a WebSocket probe.
+type webSocketOptions struct {
+	// Text message sent once connected.
+	Send string
+	// Regular expression the first message received must match, no message is awaited by default.
+	Expect string
+	// Timeout of the check, 5s by default.
+	Timeout duration
+}
+
+// webSocketChecker opens a WebSocket on ws:// and wss:// URLs, optionally exchanging a message.
+// wss:// URLs use the TLS settings of the http client of the probes.
+type webSocketChecker struct {
+	url     string
+	dialer  *websocket.Dialer
+	send    string
+	expect  *regexp.Regexp
+	timeout time.Duration
+}
+
+func newWebSocketChecker(probe Probe, client *http.Client) (checker, error) {
+	var options webSocketOptions
+	if err := decodeOptions(probe.Options, &options); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(probe.URL)
+	if err != nil {
+		return nil, err
+	}
+	c := &webSocketChecker{
+		url:     probe.URL,
+		dialer:  &websocket.Dialer{TLSClientConfig: tlsConfig(client, u.Hostname())},
+		send:    options.Send,
+		timeout: options.Timeout.or(defaultWebSocketTimeout),
+	}
+	if options.Expect != "" {
+		if c.expect, err = regexp.Compile(options.Expect); err != nil {
+			return nil, fmt.Errorf("expected reply is not a valid regular expression: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *webSocketChecker) check() result {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	start := time.Now()
+	conn, resp, err := c.dialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		if resp != nil {
+			b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+			return result{status: DownStatus, statusCode: resp.StatusCode, err: fmt.Errorf("handshake failed with status code %d: %s", resp.StatusCode, string(b))}
+		}
+		return result{status: DownStatus, err: fmt.Errorf("handshake failed: %w", err)}
+	}
+	defer func() { _ = conn.Close() }()
+	metrics := map[string]float64{"handshake_seconds": time.Since(start).Seconds()}
+	down := func(err error) result {
+		return result{status: DownStatus, statusCode: resp.StatusCode, err: err, metrics: metrics}
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return down(err)
+	}
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return down(err)
+	}
+	if c.send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(c.send)); err != nil {
+			return down(fmt.Errorf("could not send message: %w", err))
+		}
+	}
+	if c.expect != nil {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return down(fmt.Errorf("no reply received: %w", err))
+		}
+		if !c.expect.Match(message) {
+			return down(fmt.Errorf("reply %q doesn't match [%s]", quoteLimit(message, maxQuotedSize), c.expect))
+		}
+	}
+	metrics["latency_seconds"] = time.Since(start).Seconds()
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	return result{status: UpStatus, statusCode: resp.StatusCode, metrics: metrics}
+}
@@ -0,0 +1,99 @@
+package prober
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// webSocketEcho upgrades the requests on /echo and sends back every message, other paths are forbidden.
+func webSocketEcho() http.Handler {
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			kind, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(kind, message)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	return mux
+}
+
+func newTestWebSocketChecker(t *testing.T, url string, options string, client *http.Client) checker {
+	probe := NewProbe("websocket", url, 5)
+	if options != "" {
+		probe.Options = json.RawMessage(options)
+	}
+	c, err := newChecker(*probe, client)
+	if err != nil {
+		t.Fatalf("WebSocket checker should be created. got: %v\n", err)
+	}
+	return c
+}
+
+func TestWebSocketCheckerExchangesMessage(t *testing.T) {
+	server := httptest.NewServer(webSocketEcho())
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	up := []string{"", `{"Send": "ping"}`, `{"Send": "{\"op\": \"ping\"}", "Expect": "\"op\":\\s*\"ping\""}`}
+	for _, options := range up {
+		res := newTestWebSocketChecker(t, url+"/echo", options, nil).check()
+		if res.status != UpStatus || res.statusCode != http.StatusSwitchingProtocols {
+			t.Errorf("probe with options [%s] should be UP. got: %s %d %v\n", options, res.status, res.statusCode, res.err)
+		}
+		for _, metric := range []string{"handshake_seconds", "latency_seconds"} {
+			if _, ok := res.metrics[metric]; !ok {
+				t.Errorf("metric [%s] should be measured. got: %v\n", metric, res.metrics)
+			}
+		}
+	}
+
+	if res := newTestWebSocketChecker(t, url+"/echo", `{"Send": "ping", "Expect": "^pong$"}`, nil).check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe receiving an unexpected reply should be DOWN. got: %s\n", res.status)
+	}
+	if res := newTestWebSocketChecker(t, url+"/echo", `{"Expect": "ping", "Timeout": "200ms"}`, nil).check(); res.status != DownStatus || res.err == nil {
+		t.Errorf("probe receiving no reply should be DOWN. got: %s\n", res.status)
+	}
+	if res := newTestWebSocketChecker(t, url+"/admin", "", nil).check(); res.status != DownStatus || res.statusCode != http.StatusForbidden {
+		t.Errorf("probe whose handshake is refused should be DOWN with the status code. got: %s %d\n", res.status, res.statusCode)
+	}
+}
+
+func TestWebSocketCheckerUsesHttpsClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(webSocketEcho())
+	defer server.Close()
+	url := "wss" + strings.TrimPrefix(server.URL, "https") + "/echo"
+
+	if res := newTestWebSocketChecker(t, url, `{"Send": "ping", "Expect": "ping"}`, server.Client()).check(); res.status != UpStatus {
+		t.Errorf("probe trusting the CA should be UP. got: %s %v\n", res.status, res.err)
+	}
+	if res := newTestWebSocketChecker(t, url, "", http.DefaultClient).check(); res.status != DownStatus {
+		t.Errorf("probe not trusting the CA should be DOWN. got: %s\n", res.status)
+	}
+}
+
+func TestWebSocketCheckerValidation(t *testing.T) {
+	invalid := []string{`{"Expect": "("}`, `{"Send": 1}`, `{"Timeout": "soon"}`}
+	for _, options := range invalid {
+		probe := NewProbe("websocket", "wss://gateway.example.com/realtime", 5)
+		probe.Options = json.RawMessage(options)
+		if err := kindInvalid(*probe); err == nil {
+			t.Errorf("probe with options [%s] should be invalid\n", options)
+		}
+	}
+}
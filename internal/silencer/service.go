@@ -0,0 +1,169 @@
+// Service contains everything that relates to silences and maintenance windows.
+// Validation is made on this layer too.
+package silencer
+
+import (
+	"errors"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSilenceAlreadyExist = errors.New("silence with this name already exists")
+	ErrSilenceNotFound     = errors.New("silence was not found")
+)
+
+// service is an implementation of SilenceService
+type service struct {
+	persister persistence.SilencePersister
+	mu        sync.RWMutex
+	silences  map[string]*Silence
+}
+
+// NewSilenceService allow to create a new silence service.
+// Silences previously stored are loaded back in the local cache.
+func NewSilenceService(persister persistence.SilencePersister) (*service, error) {
+	s := &service{
+		persister: persister,
+		silences:  make(map[string]*Silence),
+	}
+	if err := s.loadSilences(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Insert registers the given silence.
+// Validation is made before storing the silence to be sure nothing partially configured enters the system.
+// Local cache is also updated.
+func (ss *service) Insert(silence Silence) error {
+	err := runValidators(silence, nameInvalid, probeInvalid, windowInvalid)
+	if err != nil {
+		return err
+	}
+	if err = silence.compile(); err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.silences[silence.Name]; ok {
+		return ErrSilenceAlreadyExist
+	}
+
+	err = ss.persister.InsertSilence(toEntity(silence))
+	if err != nil {
+		return err
+	}
+	ss.silences[silence.Name] = &silence
+
+	log.Printf("Silence [%s] has been successfuly created.\n", silence.Name)
+	return nil
+}
+
+// Get retrieve a silence with the given name in the system.
+// Returns the silence or ErrSilenceNotFound if no silence has been found.
+func (ss *service) Get(name string) (*Silence, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	silence, ok := ss.silences[name]
+	if !ok {
+		return nil, ErrSilenceNotFound
+	}
+	return silence, nil
+}
+
+// GetAll retrieve all silences in the system sorted by name or an empty slice.
+func (ss *service) GetAll() ([]*Silence, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	silences := make([]*Silence, 0, len(ss.silences))
+	for _, silence := range ss.silences {
+		silences = append(silences, silence)
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].Name < silences[j].Name
+	})
+	return silences, nil
+}
+
+// Delete erase an existing silence from the system.
+// Local cache is also updated.
+func (ss *service) Delete(name string) error {
+	err := runValidators(Silence{Name: name}, nameInvalid)
+	if err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, ok := ss.silences[name]; !ok {
+		return ErrSilenceNotFound
+	}
+	err = ss.persister.DeleteSilence(name)
+	if err != nil {
+		return err
+	}
+	delete(ss.silences, name)
+
+	return nil
+}
+
+// Active returns the silences sorted by name that are currently muting the given probe.
+func (ss *service) Active(probe prober.Probe) []*Silence {
+	silences, _ := ss.GetAll()
+	now := time.Now()
+	var active []*Silence
+	for _, silence := range silences {
+		if silence.Matches(probe) && silence.ActiveAt(now) {
+			active = append(active, silence)
+		}
+	}
+	return active
+}
+
+func (ss *service) loadSilences() error {
+	entities, err := ss.persister.GetAllSilences()
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		silence := fromEntity(entity)
+		if err := silence.compile(); err != nil {
+			log.Printf("[WARNING] stored silence [%s] is invalid and will be ignored. got: [%v]\n", entity.Name, err)
+			continue
+		}
+		ss.silences[silence.Name] = silence
+	}
+	return nil
+}
+
+func toEntity(silence Silence) *persistence.SilenceEntity {
+	return &persistence.SilenceEntity{
+		Name:     silence.Name,
+		Probe:    silence.Probe,
+		Selector: silence.Selector,
+		Comment:  silence.Comment,
+		StartsAt: silence.StartsAt,
+		EndsAt:   silence.EndsAt,
+		Schedule: silence.Schedule,
+		Duration: silence.Duration,
+	}
+}
+
+func fromEntity(entity *persistence.SilenceEntity) *Silence {
+	return &Silence{
+		Name:     entity.Name,
+		Probe:    entity.Probe,
+		Selector: entity.Selector,
+		Comment:  entity.Comment,
+		StartsAt: entity.StartsAt,
+		EndsAt:   entity.EndsAt,
+		Schedule: entity.Schedule,
+		Duration: entity.Duration,
+	}
+}
@@ -0,0 +1,116 @@
+package silencer
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/madjlzz/madprobe/internal/mock"
+	"github.com/madjlzz/madprobe/internal/persistence"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"testing"
+	"time"
+)
+
+func TestNewSilenceServiceLoadsStoredSilences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Return([]*persistence.SilenceEntity{
+		{Name: "deploy", Probe: "api", EndsAt: time.Now().Add(time.Hour)},
+	}, nil).Times(1)
+
+	s, err := NewSilenceService(m)
+	if err != nil {
+		t.Fatalf("no error should have been registered. got: %v\n", err)
+	}
+	if _, err := s.Get("deploy"); err != nil {
+		t.Error("stored silences should be loaded in the cache")
+	}
+}
+
+func TestInsertReturnErrorOnValidationFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Times(1)
+
+	s, _ := NewSilenceService(m)
+	err := s.Insert(Silence{Name: "deploy"})
+	if _, ok := err.(*validatorError); !ok {
+		t.Error("error should be a validation error")
+	}
+}
+
+func TestInsertReturnErrorOnInsertFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Times(1)
+	m.EXPECT().
+		InsertSilence(gomock.Any()).
+		Return(errors.New("mock InsertSilence method returns error")).
+		Times(1)
+
+	s, _ := NewSilenceService(m)
+	err := s.Insert(*NewSilence("deploy", "api", time.Time{}, time.Now().Add(time.Hour)))
+	if err == nil {
+		t.Error("failing persistent layer should result in an error")
+	}
+	if _, ok := s.silences["deploy"]; ok {
+		t.Error("cache should not be updated when persistence fails")
+	}
+}
+
+func TestInsertReturnErrorIfSilenceAlreadyExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Times(1)
+	m.EXPECT().InsertSilence(gomock.Any()).Times(1)
+
+	s, _ := NewSilenceService(m)
+	silence := NewSilence("deploy", "api", time.Time{}, time.Now().Add(time.Hour))
+	_ = s.Insert(*silence)
+
+	err := s.Insert(*silence)
+	if !errors.Is(err, ErrSilenceAlreadyExist) {
+		t.Error("returned error should be [ErrSilenceAlreadyExist]")
+	}
+}
+
+func TestDeleteReturnErrSilenceNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Times(1)
+
+	s, _ := NewSilenceService(m)
+	err := s.Delete("deploy")
+	if !errors.Is(err, ErrSilenceNotFound) {
+		t.Error("returned error should be [ErrSilenceNotFound]")
+	}
+}
+
+func TestActiveReturnsOngoingMatchingSilences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock.NewMockSilencePersister(ctrl)
+	m.EXPECT().GetAllSilences().Times(1)
+	m.EXPECT().InsertSilence(gomock.Any()).Times(3)
+
+	s, _ := NewSilenceService(m)
+	now := time.Now()
+	_ = s.Insert(*NewSilence("ongoing", "api", now.Add(-time.Minute), now.Add(time.Hour)))
+	_ = s.Insert(*NewSilence("upcoming", "api", now.Add(time.Hour), now.Add(2*time.Hour)))
+	_ = s.Insert(*NewSilence("other", "db", now.Add(-time.Minute), now.Add(time.Hour)))
+
+	active := s.Active(*prober.NewProbe("api", "http://localhost/", 5))
+	if len(active) != 1 || active[0].Name != "ongoing" {
+		t.Errorf("only the [ongoing] silence should be active. got: %v\n", active)
+	}
+}
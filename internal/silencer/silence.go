@@ -0,0 +1,104 @@
+package silencer
+
+import (
+	"github.com/madjlzz/madprobe/internal/labels"
+	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/robfig/cron/v3"
+	"regexp"
+	"time"
+)
+
+// SilenceService represent the interface used to manipulate silences.
+type SilenceService interface {
+	Insert(silence Silence) error
+	Get(name string) (*Silence, error)
+	GetAll() ([]*Silence, error)
+	Delete(name string) error
+	// Active returns the silences currently muting the given probe.
+	Active(probe prober.Probe) []*Silence
+}
+
+// Silence is the model required by the service to manipulate the resource.
+// A silence without Schedule is a one-off window going from StartsAt to EndsAt.
+// A silence with a Schedule is a recurring maintenance window lasting Duration
+// every time the cron expression fires, optionally bounded by StartsAt and EndsAt.
+// Probes are targeted by name, by label selector or both.
+type Silence struct {
+	Name string
+	// Regular expression the name of silenced probes must fully match.
+	Probe string
+	// Label selector silenced probes must satisfy, e.g. "team=payments,env!=dev".
+	Selector string
+	Comment  string
+	StartsAt time.Time
+	EndsAt   time.Time
+	// Standard cron expression (e.g. "0 2 * * 6"), "CRON_TZ=" prefix is supported.
+	Schedule string
+	Duration time.Duration
+
+	probe    *regexp.Regexp
+	selector labels.Selector
+	schedule cron.Schedule
+}
+
+// Creates a new one-off Silence with the given parameters.
+func NewSilence(name, probe string, startsAt, endsAt time.Time) *Silence {
+	return &Silence{
+		Name:     name,
+		Probe:    probe,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+	}
+}
+
+// Matches tells if the given probe is targeted by the silence.
+func (s *Silence) Matches(probe prober.Probe) bool {
+	if s.probe == nil && s.selector.Empty() {
+		return false
+	}
+	if s.probe != nil && !s.probe.MatchString(probe.Name) {
+		return false
+	}
+	return s.selector.Matches(probe.Labels)
+}
+
+// ActiveAt tells if the silence window is ongoing at the given time.
+func (s *Silence) ActiveAt(t time.Time) bool {
+	if !s.StartsAt.IsZero() && t.Before(s.StartsAt) {
+		return false
+	}
+	if !s.EndsAt.IsZero() && !t.Before(s.EndsAt) {
+		return false
+	}
+	if s.schedule == nil {
+		return true
+	}
+	// The window is open if the schedule fired during the last Duration.
+	return !s.schedule.Next(t.Add(-s.Duration)).After(t)
+}
+
+// compile prepares the probe matcher and the schedule of the silence.
+func (s *Silence) compile() error {
+	s.probe = nil
+	if s.Probe != "" {
+		probe, err := regexp.Compile("^(?:" + s.Probe + ")$")
+		if err != nil {
+			return err
+		}
+		s.probe = probe
+	}
+	selector, err := labels.Parse(s.Selector)
+	if err != nil {
+		return err
+	}
+	s.selector = selector
+	s.schedule = nil
+	if s.Schedule != "" {
+		schedule, err := cron.ParseStandard(s.Schedule)
+		if err != nil {
+			return err
+		}
+		s.schedule = schedule
+	}
+	return nil
+}
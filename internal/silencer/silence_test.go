@@ -0,0 +1,96 @@
+package silencer
+
+import (
+	"github.com/madjlzz/madprobe/internal/prober"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	s := NewSilence("deploy", "payments-.*", time.Time{}, time.Now().Add(time.Hour))
+	if err := s.compile(); err != nil {
+		t.Fatalf("silence should compile. got: %v\n", err)
+	}
+	if !s.Matches(*prober.NewProbe("payments-api", "", 5)) {
+		t.Error("probe [payments-api] should be matched by [payments-.*]")
+	}
+	if s.Matches(*prober.NewProbe("my-payments-api", "", 5)) {
+		t.Error("probe matcher should match the whole probe name")
+	}
+}
+
+func TestOneOffActiveAt(t *testing.T) {
+	start := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	s := NewSilence("deploy", "api", start, start.Add(time.Hour))
+	if err := s.compile(); err != nil {
+		t.Fatalf("silence should compile. got: %v\n", err)
+	}
+	if s.ActiveAt(start.Add(-time.Minute)) {
+		t.Error("silence should not be active before it starts")
+	}
+	if !s.ActiveAt(start.Add(30 * time.Minute)) {
+		t.Error("silence should be active during its window")
+	}
+	if s.ActiveAt(start.Add(time.Hour)) {
+		t.Error("silence should not be active once it ends")
+	}
+}
+
+func TestRecurringActiveAt(t *testing.T) {
+	s := &Silence{
+		Name:     "nightly",
+		Probe:    "api",
+		Schedule: "CRON_TZ=UTC 0 2 * * *",
+		Duration: 30 * time.Minute,
+	}
+	if err := s.compile(); err != nil {
+		t.Fatalf("silence should compile. got: %v\n", err)
+	}
+	day := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	if s.ActiveAt(day.Add(time.Hour + 59*time.Minute)) {
+		t.Error("silence should not be active before the schedule fires")
+	}
+	if !s.ActiveAt(day.Add(2 * time.Hour)) {
+		t.Error("silence should be active when the schedule fires")
+	}
+	if !s.ActiveAt(day.Add(2*time.Hour + 29*time.Minute)) {
+		t.Error("silence should be active during the window")
+	}
+	if s.ActiveAt(day.Add(2*time.Hour + 30*time.Minute)) {
+		t.Error("silence should not be active once the window is over")
+	}
+}
+
+func TestWindowInvalid(t *testing.T) {
+	now := time.Now()
+	cases := []Silence{
+		{Name: "a", Probe: "p"},
+		{Name: "a", Probe: "p", StartsAt: now, EndsAt: now.Add(-time.Hour)},
+		{Name: "a", Probe: "p", Schedule: "not a cron", Duration: time.Hour},
+		{Name: "a", Probe: "p", Schedule: "0 2 * * *"},
+	}
+	for _, c := range cases {
+		if err := windowInvalid(c); err == nil {
+			t.Errorf("silence window should be invalid: %+v\n", c)
+		}
+	}
+	if err := windowInvalid(Silence{Schedule: "0 2 * * *", Duration: time.Hour}); err != nil {
+		t.Errorf("recurring silence should be valid. got: %v\n", err)
+	}
+}
+
+func TestMatchesSelector(t *testing.T) {
+	s := &Silence{Name: "deploy", Selector: "team=payments,env!=dev", EndsAt: time.Now().Add(time.Hour)}
+	if err := s.compile(); err != nil {
+		t.Fatalf("silence should compile. got: %v\n", err)
+	}
+	probe := prober.NewProbe("api", "", 5)
+	probe.Labels = map[string]string{"team": "payments", "env": "prod"}
+	if !s.Matches(*probe) {
+		t.Error("probe labels should be matched by the selector")
+	}
+	probe.Labels["env"] = "dev"
+	if s.Matches(*probe) {
+		t.Error("probe labels should not be matched by the selector")
+	}
+}
@@ -0,0 +1,104 @@
+package silencer
+
+import (
+	"fmt"
+	"github.com/madjlzz/madprobe/internal/labels"
+	"github.com/robfig/cron/v3"
+	"regexp"
+)
+
+// Custom error type that occurs when there is a validation error.
+type validatorError struct {
+	field string
+	msg   string
+}
+
+// Implementation of the error interface.
+// Prints out a validationError.
+func (ve *validatorError) Error() string {
+	return fmt.Sprintf("Field [%s]: %s\n", ve.field, ve.msg)
+}
+
+// Validate the name property of the silence.
+// Returns an error if the name is empty.
+func nameInvalid(silence Silence) error {
+	if silence.Name == "" {
+		return &validatorError{
+			field: "Name",
+			msg:   "name is required",
+		}
+	}
+	return nil
+}
+
+// Validate the probe matchers of the silence.
+// Returns an error if both are empty, if the probe is not a valid regular expression
+// or if the selector can't be parsed.
+func probeInvalid(silence Silence) error {
+	if silence.Probe == "" && silence.Selector == "" {
+		return &validatorError{
+			field: "Probe",
+			msg:   "probe or selector is required",
+		}
+	}
+	if _, err := labels.Parse(silence.Selector); err != nil {
+		return &validatorError{
+			field: "Selector",
+			msg:   err.Error(),
+		}
+	}
+	if _, err := regexp.Compile(silence.Probe); err != nil {
+		return &validatorError{
+			field: "Probe",
+			msg:   "probe must be a valid regular expression",
+		}
+	}
+	return nil
+}
+
+// Validate the window of the silence.
+// A one-off silence must end after it starts, a recurring one needs a valid schedule and duration.
+func windowInvalid(silence Silence) error {
+	if !silence.EndsAt.IsZero() && !silence.EndsAt.After(silence.StartsAt) {
+		return &validatorError{
+			field: "EndsAt",
+			msg:   "EndsAt must be after StartsAt",
+		}
+	}
+	if silence.Schedule == "" {
+		if silence.EndsAt.IsZero() {
+			return &validatorError{
+				field: "EndsAt",
+				msg:   "EndsAt is required for a one-off silence",
+			}
+		}
+		return nil
+	}
+	if _, err := cron.ParseStandard(silence.Schedule); err != nil {
+		return &validatorError{
+			field: "Schedule",
+			msg:   "Schedule must be a valid cron expression",
+		}
+	}
+	if silence.Duration <= 0 {
+		return &validatorError{
+			field: "Duration",
+			msg:   "Duration must be strictly positive for a recurring silence",
+		}
+	}
+	return nil
+}
+
+// Handy type that allow us to pass a function that takes a silence
+// for validation.
+type validateFunc func(silence Silence) error
+
+// Runs all of the given validator functions for the passed silence.
+func runValidators(silence Silence, fns ...validateFunc) error {
+	for _, fn := range fns {
+		if err := fn(silence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
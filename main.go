@@ -2,12 +2,12 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/madjlzz/madprobe/controller"
 	"github.com/madjlzz/madprobe/internal/alerter"
 	"github.com/madjlzz/madprobe/internal/persistence"
 	"github.com/madjlzz/madprobe/internal/prober"
+	"github.com/madjlzz/madprobe/internal/silencer"
 	"github.com/madjlzz/madprobe/util"
 	"log"
 	"net/http"
@@ -23,9 +23,15 @@ func main() {
 	if len(configuration.CaCertificate) > 0 {
 		client = util.HttpsClient(configuration.CaCertificate)
 	}
+	if len(configuration.ExecPluginsDir) > 0 {
+		prober.EnableExecProbes(configuration.ExecPluginsDir)
+	}
+	if len(configuration.LocalProbesRoot) > 0 {
+		prober.EnableLocalProbes(configuration.LocalProbesRoot)
+	}
 
 	// Event Bus channel to let services communicate.
-	alertBus := make(chan prober.Probe)
+	alertBus := make(chan prober.Event)
 	// TODO: should be passed as a property...
 	persistenceClient, err := persistence.NewBoltDBClient("madprobe.db")
 	if err != nil {
@@ -34,7 +40,17 @@ func main() {
 
 	probeRunner := prober.NewProbeRunner(client, alertBus)
 	probeService := prober.NewProbeService(probeRunner, persistenceClient)
-	probeController := controller.NewProbeController(probeService)
+	silenceService, err := silencer.NewSilenceService(persistenceClient)
+	if err != nil {
+		log.Fatalf("[ERROR] silence module wasn't able to initialize. got: %v\n", err)
+	}
+	al, err := alerter.NewService(alertBus, probeService, silenceService, persistenceClient, persistenceClient, persistenceClient)
+	if err != nil {
+		log.Fatalf("[ERROR] alerter module wasn't able to start. got: %v\n", err)
+	}
+	probeController := controller.NewProbeController(probeService, silenceService, al)
+	silenceController := controller.NewSilenceController(silenceService)
+	alerterController := controller.NewAlerterController(al)
 
 	r := mux.NewRouter()
 	r.HandleFunc("/api/v1/probe/create", probeController.Create).
@@ -45,6 +61,24 @@ func main() {
 		Methods(http.MethodGet)
 	r.HandleFunc("/api/v1/probe/{name}", probeController.Delete).
 		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/probe/{name}/ack", probeController.Ack).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/silence/create", silenceController.Create).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/silence/{name}", silenceController.Read).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/silence", silenceController.ReadAll).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/silence/{name}", silenceController.Delete).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/alerters", alerterController.ReadAll).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/alerters/dead-letters", alerterController.ReadAllDeadLetters).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/alerters/dead-letters/{id}/replay", alerterController.Replay).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/alerters/{name}/preview", alerterController.Preview).
+		Methods(http.MethodGet)
 
 	srv := &http.Server{
 		Addr: "0.0.0.0:" + configuration.Port,
@@ -71,10 +105,6 @@ func main() {
 	}()
 
 	// Alerter start at boot time.
-	al, err := alerter.NewService(alertBus)
-	if err != nil {
-		fmt.Printf("[WARNING] alerter module wasn't able to start. got: %v\n", err)
-	}
 	al.Run()
 
 	c := make(chan os.Signal, 1)
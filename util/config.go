@@ -16,6 +16,10 @@ type ServerConfiguration struct {
 	ServerKey string
 	// the CA certificate
 	CaCertificate string
+	// the directory of the commands exec probes may run, exec probes are disabled when empty
+	ExecPluginsDir string
+	// the directory file and fs probes may look under, file and fs probes are disabled when empty
+	LocalProbesRoot string
 }
 
 // Default value of the ServerConfiguration struct.
@@ -25,6 +29,8 @@ var DefaultServerConfiguration = &ServerConfiguration{
 	ServerCertificate: "",
 	ServerKey:         "",
 	CaCertificate:     "",
+	ExecPluginsDir:    "",
+	LocalProbesRoot:   "",
 }
 
 // Insert a new ServerConfiguration with default values or values coming from Viper.
@@ -35,5 +41,7 @@ func NewServerConfiguration() *ServerConfiguration {
 		ServerCertificate: viper.GetString("cert"),
 		ServerKey:         viper.GetString("key"),
 		CaCertificate:     viper.GetString("ca-cert"),
+		ExecPluginsDir:    viper.GetString("exec-plugins-dir"),
+		LocalProbesRoot:   viper.GetString("local-probes-root"),
 	}
 }
@@ -22,6 +22,8 @@ func serverFlags() {
 	ViperFlagSet.String("cert", DefaultServerConfiguration.ServerCertificate, "public certificate shown by the server to it's clients")
 	ViperFlagSet.String("key", DefaultServerConfiguration.ServerKey, "the server's certificate private key")
 	ViperFlagSet.String("ca-cert", DefaultServerConfiguration.CaCertificate, "the CA certificate")
+	ViperFlagSet.String("exec-plugins-dir", DefaultServerConfiguration.ExecPluginsDir, "the directory of the commands exec probes may run, exec probes are disabled when empty")
+	ViperFlagSet.String("local-probes-root", DefaultServerConfiguration.LocalProbesRoot, "the directory file and fs probes and the pidfiles of process probes may look under, they are disabled when empty")
 }
 
 func discordFlags() {